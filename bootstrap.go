@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go-circle-of-friends/internal/config"
+	"go-circle-of-friends/internal/fetch"
+	"go-circle-of-friends/internal/logx"
+	"go-circle-of-friends/internal/rules"
+	"go-circle-of-friends/internal/sources"
+	"go-circle-of-friends/internal/store"
+)
+
+// commonFlags 为各子命令共享的 flag（配置与规则路径）。
+type commonFlags struct {
+	configPath string
+	rulesPath  string
+	recordDir  string
+	replayDir  string
+}
+
+// newFlagSet 创建子命令的 FlagSet 并注册共享 flag。
+func newFlagSet(c *commonFlags, name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&c.configPath, "config", "settings.yaml", "path to settings.yaml")
+	fs.StringVar(&c.rulesPath, "rules", "rules.yaml", "path to rules.yaml (optional)")
+	fs.StringVar(&c.recordDir, "record", "", "record every HTTP response into this directory")
+	fs.StringVar(&c.replayDir, "replay", "", "serve HTTP strictly from responses recorded in this directory (no network)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: cof %s %s\n\nflags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// env 为子命令共享的运行环境：配置、规则（可能为 nil）与录制/回放目录。
+type env struct {
+	cfg       *config.Config
+	rules     *rules.Rules
+	recordDir string
+	replayDir string
+}
+
+// load 加载配置与规则并初始化日志；规则加载失败仅告警。
+func (c *commonFlags) load() (*env, error) {
+	cfg, err := config.Load(c.configPath)
+	if err != nil {
+		return nil, err
+	}
+	var rl *rules.Rules
+	if c.rulesPath != "" {
+		if r, err := rules.Load(c.rulesPath); err == nil {
+			rl = r
+		} else {
+			log.Printf("load rules failed: %v", err)
+		}
+	}
+	if err := sources.Validate(cfg.LinkSources); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+	// 初始化日志：级别/格式/语言/颜色
+	logx.Init(cfg.LogLevel, cfg.LogFormat, cfg.LogLocale, cfg.LogColor)
+	if c.recordDir != "" && c.replayDir != "" {
+		return nil, fmt.Errorf("-record and -replay are mutually exclusive")
+	}
+	return &env{cfg: cfg, rules: rl, recordDir: c.recordDir, replayDir: c.replayDir}, nil
+}
+
+// fetchOptions 由配置生成 HTTP 客户端参数（含代理、重试、限速、请求配置与安全限制）。
+func (e *env) fetchOptions() fetch.Options {
+	opts := fetch.Options{
+		ProxyHTTP:  e.cfg.Proxy.HTTP,
+		ProxyHTTPS: e.cfg.Proxy.HTTPS,
+		Timeout:    25 * time.Second,
+		Retry:      e.cfg.Concurrency.Retry,
+		RecordDir:  e.recordDir,
+		ReplayDir:  e.replayDir,
+		MaxPerHost: e.cfg.Concurrency.PerHost,
+		HostDelay:  e.cfg.Concurrency.HostDelay,
+		RPS:        e.cfg.Concurrency.RPS,
+		UserAgent:  e.cfg.HTTP.UserAgent,
+		Headers:    e.cfg.HTTP.Headers,
+
+		MaxPageBytes: int64(e.cfg.HTTP.MaxPageMB) << 20,
+		MaxFeedBytes: int64(e.cfg.HTTP.MaxFeedMB) << 20,
+		MaxRedirects: e.cfg.HTTP.MaxRedirects,
+		BlockPrivate: !e.cfg.AllowPrivateNetworks,
+		Robots:       e.cfg.HTTP.RobotsTxt,
+		Hosts:        e.cfg.DNS.Hosts,
+		Resolver:     e.cfg.DNS.Resolver,
+	}
+	for _, r := range e.cfg.Proxy.Rules {
+		opts.ProxyRules = append(opts.ProxyRules, fetch.ProxyRule{Suffix: r.Suffix, Regex: r.Regex, Proxy: r.Proxy})
+	}
+	for _, h := range e.cfg.HTTP.Hosts {
+		opts.HostProfiles = append(opts.HostProfiles, fetch.HostProfile{
+			Host:      h.Host,
+			UserAgent: h.UserAgent,
+			Headers:   h.Headers,
+			Cookies:   h.Cookies,
+
+			CAFile:             h.CAFile,
+			InsecureSkipVerify: h.InsecureSkipVerify,
+		})
+	}
+	return opts
+}
+
+// client 按配置创建 HTTP 客户端。
+func (e *env) client() (*fetch.Client, error) {
+	return newClient(e.fetchOptions())
+}
+
+func newClient(opts fetch.Options) (*fetch.Client, error) {
+	cl, err := fetch.New(opts)
+	if err != nil {
+		return nil, fmt.Errorf("http client: %w", err)
+	}
+	return cl, nil
+}
+
+// openStore 打开配置中的数据库（自动迁移）。
+func (e *env) openStore() (*store.SQLite, error) {
+	st, err := store.OpenSQLite(e.cfg.Database.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+	return st, nil
+}
+
+// openExistingStore 与 openStore 相同，但要求数据库文件已存在，
+// 避免只读类命令（export/serve/db）对错误路径隐式建库。
+func (e *env) openExistingStore() (*store.SQLite, error) {
+	dsn := e.cfg.Database.DSN
+	if !strings.HasPrefix(dsn, "file:") {
+		if _, err := os.Stat(dsn); err != nil {
+			return nil, fmt.Errorf("database %s: %w", dsn, err)
+		}
+	}
+	return e.openStore()
+}
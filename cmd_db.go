@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go-circle-of-friends/internal/logx"
+)
+
+// cmdDB 数据库维护：vacuum（整理空间）、reset（清空表）、stats（打印统计）。
+func cmdDB(args []string) error {
+	var c commonFlags
+	fs := newFlagSet(&c, "db", "[flags] vacuum|reset|stats")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one action, got %d", fs.NArg())
+	}
+	action := fs.Arg(0)
+	e, err := c.load()
+	if err != nil {
+		return err
+	}
+	st, err := e.openExistingStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	ctx := context.Background()
+	switch action {
+	case "vacuum":
+		if err := st.Vacuum(ctx); err != nil {
+			return err
+		}
+		logx.Infof("已整理数据库：%s", e.cfg.Database.DSN)
+	case "reset":
+		if err := st.Reset(ctx); err != nil {
+			return err
+		}
+		logx.Infof("已清理数据库表（friends/posts）")
+	case "stats":
+		s, err := st.Stats(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("friends_total  %d\n", s.FriendsTotal)
+		fmt.Printf("friends_alive  %d\n", s.FriendsAlive)
+		fmt.Printf("friends_error  %d\n", s.FriendsError)
+		fmt.Printf("posts_total    %d\n", s.PostsTotal)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"go-circle-of-friends/internal/logx"
+	"go-circle-of-friends/internal/sources"
+)
+
+// cmdDiscover 仅解析 LINK 来源并打印朋友列表，用于调试 rules.yaml。
+func cmdDiscover(args []string) error {
+	var c commonFlags
+	fs := newFlagSet(&c, "discover", "[flags]")
+	_ = fs.Parse(args)
+	e, err := c.load()
+	if err != nil {
+		return err
+	}
+	return discoverFriends(context.Background(), e)
+}
+
+func discoverFriends(ctx context.Context, e *env) error {
+	cl, err := e.client()
+	if err != nil {
+		return err
+	}
+	total, failed := 0, 0
+	for _, res := range sources.Collect(ctx, e.cfg.LinkSources, sources.Deps{Fetch: cl, Rules: e.rules}) {
+		if res.Err != nil {
+			logx.Errorf("解析友链来源失败：[%s] %s 错误=%v", res.Source.Type, res.Source.Location(), res.Err)
+			failed++
+			continue
+		}
+		logx.Infof("[%s] %s 解析到 %d 位朋友", res.Source.Type, res.Source.Location(), len(res.Friends))
+		for _, f := range res.Friends {
+			if f.Feed != "" {
+				logx.Infof("- 名称=%q 链接=%s 头像=%s 订阅=%s", f.Name, f.Link, f.Avatar, f.Feed)
+				continue
+			}
+			logx.Infof("- 名称=%q 链接=%s 头像=%s", f.Name, f.Link, f.Avatar)
+		}
+		total += len(res.Friends)
+	}
+	if failed > 0 {
+		logx.Warnf("%d 个友链来源解析失败", failed)
+	}
+	if total == 0 {
+		logx.Warnf("未从友链来源发现朋友，请检查 LINK.url 与 rules.yaml 选择器或 JSON 字段映射。")
+	}
+	return nil
+}
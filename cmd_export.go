@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"go-circle-of-friends/internal/export"
+	"go-circle-of-friends/internal/logx"
+)
+
+// cmdExport 从已有数据库重新生成 data.json（及可选的 OPML），不抓取。
+func cmdExport(args []string) error {
+	var c commonFlags
+	fs := newFlagSet(&c, "export", "[flags]")
+	exportPath := fs.String("export", "data.json", "output json path (empty skips json)")
+	opmlPath := fs.String("opml", "", "also write alive friends' feeds as OPML to this path")
+	_ = fs.Parse(args)
+	if *exportPath == "" && *opmlPath == "" {
+		return errors.New("-export or -opml path is required")
+	}
+	e, err := c.load()
+	if err != nil {
+		return err
+	}
+	st, err := e.openExistingStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	ctx := context.Background()
+	if *exportPath != "" {
+		if err := export.ToJSON(ctx, st, *exportPath); err != nil {
+			return err
+		}
+		logx.Infof("已从数据库 %s 导出 %s", e.cfg.Database.DSN, *exportPath)
+	}
+	if *opmlPath != "" {
+		if err := export.ToOPML(ctx, st, *opmlPath); err != nil {
+			return err
+		}
+		logx.Infof("已从数据库 %s 导出 %s", e.cfg.Database.DSN, *opmlPath)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go-circle-of-friends/internal/feeds"
+)
+
+// cmdProbe 对单个站点执行订阅发现与解析，打印完整探测轨迹：
+// 每个候选的状态码/Content-Type/嗅探结论/耗时、首页 <link> 声明以及解析出的前几篇文章。
+func cmdProbe(args []string) error {
+	var c commonFlags
+	fs := newFlagSet(&c, "probe", "[flags] <url>")
+	suffix := fs.String("feed-suffix", "", "optional feed suffix to try first, e.g. /atom.xml")
+	items := fs.Int("items", 5, "number of parsed items to print")
+	_ = fs.Parse(args)
+	// flag 包遇到第一个位置参数即停止解析，这里继续解析其后的 flag，支持 cof probe <url> -items 3
+	var pos []string
+	for fs.NArg() > 0 {
+		pos = append(pos, fs.Arg(0))
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	if len(pos) != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one url, got %d", len(pos))
+	}
+	site := pos[0]
+	e, err := c.load()
+	if err != nil {
+		return err
+	}
+	cl, err := e.client()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	feedURL, tr, derr := feeds.DiscoverFeedTrace(ctx, cl, site, *suffix, feeds.WithConcurrency(e.cfg.Concurrency.Probe))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tSOURCE\tSTATUS\tCONTENT-TYPE\tVERDICT\tTIME\tURL")
+	for i, a := range tr.Attempts {
+		status := "-"
+		if a.Status != 0 {
+			status = fmt.Sprint(a.Status)
+		}
+		mark := ""
+		if a.OK {
+			mark = " <= feed"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s%s\n", i+1, a.Source, status, orDash(a.ContentType), a.Verdict, a.Duration.Round(time.Millisecond), a.URL, mark)
+	}
+	_ = w.Flush()
+	for _, a := range tr.Attempts {
+		if a.Err != "" && a.Status == 0 {
+			fmt.Printf("  %s: %s\n", a.URL, a.Err)
+		}
+	}
+
+	// 候选路径命中时不会抓取首页，这里补充抓取以展示 <link> 声明
+	tags := tr.LinkTags
+	if tags == nil && tr.HTMLErr == "" {
+		if tags, err = feeds.FindLinkTags(ctx, cl, site); err != nil {
+			tr.HTMLErr = err.Error()
+		}
+	}
+	fmt.Println()
+	if tr.HTMLErr != "" {
+		fmt.Printf("<link> tags: homepage error: %s\n", tr.HTMLErr)
+	} else {
+		fmt.Printf("<link> tags: %d\n", len(tags))
+		for _, t := range tags {
+			fmt.Printf("  rel=%q type=%q href=%s\n", t.Rel, t.Type, t.Href)
+		}
+	}
+
+	fmt.Println()
+	if derr != nil {
+		return derr
+	}
+	fmt.Printf("feed: %s\n", feedURL)
+	res, err := feeds.ParseFeedDetail(ctx, cl, feedURL, *items)
+	if err != nil {
+		return err
+	}
+	if len(res.Repairs) > 0 {
+		fmt.Printf("repaired: %s\n", strings.Join(res.Repairs, ","))
+	}
+	fmt.Printf("items: %d\n", len(res.Items))
+	for _, it := range res.Items {
+		fmt.Printf("  %s  %s  %s\n", it.Created.Format("2006-01-02"), it.Title, it.Link)
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go-circle-of-friends/internal/aggregate"
+	"go-circle-of-friends/internal/export"
+	"go-circle-of-friends/internal/fetch"
+	"go-circle-of-friends/internal/logx"
+	"go-circle-of-friends/internal/schedule"
+	"go-circle-of-friends/internal/store"
+)
+
+// cmdRun 执行聚合：单次运行或按 SCHEDULE 常驻。
+func cmdRun(args []string) error {
+	var c commonFlags
+	fs := newFlagSet(&c, "run", "[flags]")
+	exportPath := fs.String("export", "data.json", "export json path (empty disables export in normal mode)")
+	opmlPath := fs.String("opml", "", "also export alive friends' feeds as OPML to this path after each run")
+	daemon := fs.Bool("daemon", false, "keep running and re-aggregate on the SCHEDULE from settings.yaml")
+	// 旧版 flag：保留以兼容已有脚本，转交给对应子命令
+	discover := fs.Bool("discover", false, "deprecated: use `cof discover`")
+	serveMode := fs.Bool("serve", false, "deprecated: use `cof serve`")
+	addr := fs.String("addr", ":8000", "deprecated: use `cof serve -addr`")
+	_ = fs.Parse(args)
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	e, err := c.load()
+	if err != nil {
+		return err
+	}
+	switch {
+	case *discover:
+		logx.Warnf("-discover 已废弃，请使用 `cof discover`")
+		return discoverFriends(context.Background(), e)
+	case *serveMode:
+		logx.Warnf("-serve 已废弃，请使用 `cof serve`")
+		return serve(e, *addr)
+	}
+
+	// 数据存储：极简模式不打开数据库（使用状态文件）；正常模式打开并按需重置
+	ctx := context.Background()
+	opts := e.fetchOptions()
+	var st *store.SQLite
+	var state *aggregate.SimpleState
+	if !e.cfg.SimpleMode {
+		st, err = e.openStore()
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+		if e.cfg.ResetOnStart {
+			if err := st.Reset(ctx); err != nil {
+				logx.Warnf("启动清理数据库失败：%v", err)
+			} else {
+				logx.Infof("已清理数据库表（friends/posts）")
+			}
+		}
+		opts.Validators = st
+	} else {
+		if e.cfg.ResetOnStart {
+			// 极简模式仅删除导出文件，不操作数据库；状态文件保留，否则条件请求的校验值与 304 时恢复的文章随之丢失
+			logx.Infof("极简模式：跳过数据库打开与清理，保留状态文件")
+		}
+		state, err = aggregate.LoadSimpleState(e.cfg.StateFile)
+		if err != nil {
+			return err
+		}
+		opts.Validators = state
+	}
+	if e.cfg.ResetOnStart && *exportPath != "" {
+		if err := os.Remove(*exportPath); err == nil {
+			logx.Infof("已删除导出文件：%s", *exportPath)
+		}
+	}
+	cl, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+
+	if *daemon {
+		// 常驻模式：复用已初始化的客户端/数据库，按 SCHEDULE 周期运行
+		return runDaemon(e, st, state, cl, *exportPath, *opmlPath)
+	}
+	if err := runOnce(ctx, e, st, state, cl, *exportPath, *opmlPath); err != nil {
+		logx.Errorf("运行失败：%v", err)
+		return err
+	}
+	return nil
+}
+
+// runOnce 执行一轮聚合并导出 JSON 与可选的 OPML（极简模式读内存，正常模式读数据库）。
+func runOnce(ctx context.Context, e *env, st *store.SQLite, state *aggregate.SimpleState, cl *fetch.Client, exportPath, opmlPath string) error {
+	run := aggregate.New(e.cfg, st, cl, e.rules).UseState(state)
+	logx.Infof("开始聚合：极简模式=%v", e.cfg.SimpleMode)
+	if err := run.Run(ctx); err != nil {
+		return err
+	}
+	if state != nil {
+		if err := state.Save(); err != nil {
+			logx.Warnf("保存状态文件失败：%v", err)
+		}
+	}
+	if e.cfg.SimpleMode {
+		// 极简导出：只导出 JSON，跳过写库
+		fr, ps := run.BufferData()
+		if err := export.ToJSONData(ctx, fr, ps, exportPath); err != nil {
+			return fmt.Errorf("export json: %w", err)
+		}
+		logx.Infof("已导出 %s", exportPath)
+		if opmlPath != "" {
+			if err := export.ToOPMLData(ctx, fr, opmlPath); err != nil {
+				return fmt.Errorf("export opml: %w", err)
+			}
+			logx.Infof("已导出 %s", opmlPath)
+		}
+		return nil
+	}
+	// 正常模式：从数据库导出
+	if exportPath != "" {
+		if err := export.ToJSON(ctx, st, exportPath); err != nil {
+			return fmt.Errorf("export json: %w", err)
+		}
+		logx.Infof("已从数据库导出 %s", exportPath)
+	}
+	if opmlPath != "" {
+		if err := export.ToOPML(ctx, st, opmlPath); err != nil {
+			return fmt.Errorf("export opml: %w", err)
+		}
+		logx.Infof("已从数据库导出 %s", opmlPath)
+	}
+	return nil
+}
+
+// runDaemon 按 SCHEDULE 周期执行 runOnce，收到 SIGINT/SIGTERM 时取消当前一轮并退出。
+func runDaemon(e *env, st *store.SQLite, state *aggregate.SimpleState, cl *fetch.Client, exportPath, opmlPath string) error {
+	sc := e.cfg.Schedule
+	var spec schedule.Spec
+	switch {
+	case sc.Cron != "":
+		c, err := schedule.ParseCron(sc.Cron)
+		if err != nil {
+			return err
+		}
+		spec = c
+	case sc.Interval > 0:
+		spec = schedule.Every(sc.Interval)
+	default:
+		return errors.New("SCHEDULE.cron or SCHEDULE.interval is required in daemon mode")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logx.Infof("常驻模式已启动：cron=%q 间隔=%s 抖动=%s", sc.Cron, sc.Interval, sc.Jitter)
+	schedule.Run(ctx, schedule.Options{
+		Spec:       spec,
+		Jitter:     sc.Jitter,
+		RunOnStart: sc.RunOnStart,
+	}, func(ctx context.Context) error {
+		return runOnce(ctx, e, st, state, cl, exportPath, opmlPath)
+	})
+	logx.Infof("常驻模式已退出")
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go-circle-of-friends/internal/api"
+	"go-circle-of-friends/internal/logx"
+)
+
+// cmdServe 基于数据库提供只读 HTTP 接口，不抓取。
+func cmdServe(args []string) error {
+	var c commonFlags
+	fs := newFlagSet(&c, "serve", "[flags]")
+	addr := fs.String("addr", ":8000", "listen address")
+	_ = fs.Parse(args)
+	e, err := c.load()
+	if err != nil {
+		return err
+	}
+	return serve(e, *addr)
+}
+
+// serve 打开已有数据库并启动 HTTP 接口，收到 SIGINT/SIGTERM 后优雅退出。
+func serve(e *env, addr string) error {
+	st, err := e.openExistingStore()
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           api.New(st),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	logx.Infof("接口服务已启动：%s（数据库=%s）", addr, e.cfg.Database.DSN)
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	logx.Infof("正在关闭接口服务…")
+	shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
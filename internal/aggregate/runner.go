@@ -6,6 +6,7 @@ package aggregate
 
 import (
 	"context"
+	"errors"
 	"net/url"
 	"strings"
 	"sync"
@@ -14,25 +15,29 @@ import (
 	"go-circle-of-friends/internal/config"
 	"go-circle-of-friends/internal/feeds"
 	"go-circle-of-friends/internal/fetch"
-	"go-circle-of-friends/internal/friends"
 	"go-circle-of-friends/internal/logx"
 	"go-circle-of-friends/internal/model"
 	"go-circle-of-friends/internal/rules"
+	"go-circle-of-friends/internal/sources"
 	"go-circle-of-friends/internal/store"
 )
 
-// Runner 聚合执行器，持有配置/存储/HTTP 客户端/规则。
+// Runner 聚合执行器，持有配置/存储/抓取器/规则。
 type Runner struct {
 	cfg   *config.Config
 	rules *rules.Rules
-	fetch *fetch.Client
+	fetch fetch.Fetcher
 	store *store.SQLite
 	// 简洁模式：仅收集内存数据，不落库
 	buf *SimpleBuffer
+	// 极简模式的跨运行状态：订阅未变化（304）时从中恢复文章，可为空
+	state *SimpleState
+	// 最近一轮各友链来源的解析结果
+	sources []sources.Result
 }
 
 // New 创建 Runner。
-func New(cfg *config.Config, s *store.SQLite, cl *fetch.Client, rl *rules.Rules) *Runner {
+func New(cfg *config.Config, s *store.SQLite, cl fetch.Fetcher, rl *rules.Rules) *Runner {
 	r := &Runner{cfg: cfg, store: s, fetch: cl, rules: rl}
 	if cfg != nil && cfg.SimpleMode {
 		r.buf = NewSimpleBuffer()
@@ -40,31 +45,29 @@ func New(cfg *config.Config, s *store.SQLite, cl *fetch.Client, rl *rules.Rules)
 	return r
 }
 
-// Run 执行一轮聚合：发现朋友→发现订阅→解析文章→清理过期。
+// UseState 设置极简模式的跨运行状态（需与 fetch 客户端的 Validators 为同一实例）。
+func (r *Runner) UseState(s *SimpleState) *Runner {
+	r.state = s
+	return r
+}
+
+// Run 执行一轮聚合：发现朋友→发现订阅（正常模式复用缓存）→解析文章→清理过期。
 func (r *Runner) Run(ctx context.Context) error {
-	// 构建朋友列表（静态 + 页面来源）
-	friendsList := dedup(r.cfg.StaticFriends)
-	logx.Infof("静态朋友=%d，页面来源=%d", len(r.cfg.StaticFriends), len(r.cfg.LinkSources))
-	for _, src := range r.cfg.LinkSources {
-		if src.Type != "page" {
+	// 构建朋友列表（静态 + 页面来源）；已迁移的旧链接换成当前链接后再去重
+	aliases := r.aliases(ctx)
+	friendsList := dedup(canonicalize(r.cfg.StaticFriends, aliases))
+	logx.Infof("静态朋友=%d，友链来源=%d", len(r.cfg.StaticFriends), len(r.cfg.LinkSources))
+	r.sources = sources.Collect(ctx, r.cfg.LinkSources, sources.Deps{Fetch: r.fetch, Rules: r.rules})
+	for _, res := range r.sources {
+		if res.Err != nil {
+			logx.Warnf("解析友链来源失败：[%s] %s 错误=%v", res.Source.Type, res.Source.Location(), res.Err)
 			continue
 		}
-		var preset rules.Preset
-		if r.rules != nil {
-			if p, ok := r.rules.GetPreset(src.Theme); ok {
-				preset = p
-			}
-		}
-		found, err := friends.ParseFriendsPage(ctx, r.fetch, src.URL, preset)
-		if err != nil {
-			logx.Warnf("解析友链页失败：%s 错误=%v", src.URL, err)
-			continue
-		}
-		logx.Infof("%s 解析到 %d 位朋友", src.URL, len(found))
-		friendsList = mergeDedup(friendsList, found)
+		logx.Infof("[%s] %s 解析到 %d 位朋友", res.Source.Type, res.Source.Location(), len(res.Friends))
+		friendsList = mergeDedup(friendsList, canonicalize(res.Friends, aliases))
 	}
 	if len(friendsList) == 0 {
-		logx.Warnf("没有发现任何朋友（静态或页面）")
+		logx.Warnf("没有发现任何朋友（静态或友链来源）")
 	}
 
 	sem := make(chan struct{}, max(1, r.cfg.Concurrency.Fetch))
@@ -90,7 +93,7 @@ func (r *Runner) Run(ctx context.Context) error {
 	return nil
 }
 
-// processFriend 处理单个朋友：订阅发现→解析→写库。
+// processFriend 处理单个朋友：订阅发现（优先复用缓存）→解析→写库。
 func (r *Runner) processFriend(ctx context.Context, sf config.StaticFriend) {
 	host := hostOf(sf.Link)
 	f := model.Friend{
@@ -99,52 +102,335 @@ func (r *Runner) processFriend(ctx context.Context, sf config.StaticFriend) {
 		Avatar:    sf.Avatar,
 		CreatedAt: time.Now(),
 	}
+	// 来源已给出订阅地址时直接解析，不做发现
+	if sf.Feed != "" {
+		f.Feed = sf.Feed
+		res, err := r.collectPosts(ctx, sf, sf.Feed, "订阅")
+		if err != nil {
+			f.Error = friendError(err)
+			logx.Warnf("[%s|%s] 解析订阅失败：%v", sf.Name, host, err)
+		}
+		f.Repaired = strings.Join(res.Repairs, ",")
+		r.followMoves(ctx, &f, "", res.Moved)
+		r.saveFriend(ctx, f)
+		return
+	}
+	// 优先复用缓存的订阅地址；解析失败时清除缓存并回退到完整发现
+	if feedURL, ok := r.cachedFeed(ctx, sf.Link); ok {
+		res, err := r.collectPosts(ctx, sf, feedURL, "缓存订阅")
+		if err == nil {
+			f.Feed = feedURL
+			f.Repaired = strings.Join(res.Repairs, ",")
+			r.followMoves(ctx, &f, "", res.Moved)
+			r.saveFriend(ctx, f)
+			return
+		}
+		logx.Infof("[%s|%s] 缓存订阅不可用，重新发现：%v", sf.Name, host, err)
+		if err := r.store.DeleteFeedCache(ctx, sf.Link); err != nil {
+			logx.Warnf("删除订阅缓存失败：%v", err)
+		}
+	}
 	// 发现订阅
-	feedURL, err := feeds.DiscoverFeed(ctx, r.fetch, sf.Link, sf.FeedSuffix)
+	feedURL, tr, err := feeds.DiscoverFeedTrace(ctx, r.fetch, sf.Link, sf.FeedSuffix, feeds.WithConcurrency(r.cfg.Concurrency.Probe))
 	if err != nil {
-		f.Error = err.Error()
-		if r.buf != nil {
-			r.buf.AddFriend(f)
-		} else {
-			_ = r.store.UpsertFriend(ctx, f)
-		}
+		f.Error = friendError(err)
+		r.saveFriend(ctx, f)
 		logx.Warnf("[%s|%s] 发现订阅失败：%v", sf.Name, host, err)
 		return
 	}
+	r.rememberFeed(ctx, sf.Link, tr)
 	f.Error = ""
+	f.Feed = feedURL
+	// 解析文章条目（订阅解析失败不影响朋友本身的状态）
+	res, err := r.collectPosts(ctx, sf, feedURL, "订阅")
+	if err != nil {
+		logx.Warnf("[%s|%s] 解析订阅失败：%v", sf.Name, host, err)
+		if errors.Is(err, fetch.ErrDisallowed) {
+			f.Error = friendError(err)
+		}
+	}
+	f.Repaired = strings.Join(res.Repairs, ",")
+	r.followMoves(ctx, &f, tr.SiteMoved, res.Moved)
+	r.saveFriend(ctx, f)
+}
+
+// followMoves 处理永久重定向（301/308）：订阅迁移时改用新订阅地址；
+// 站点迁移（首页永久重定向）时将朋友链接改为新地址，旧链接记为别名以便后续运行中配置里的旧链接仍能去重。
+// 订阅迁移本身不足以说明站点迁移（订阅可能迁到 FeedBurner 等第三方主机），仅同主机的协议升级直接沿用，
+// 迁到其他主机时需再探测首页确认。
+func (r *Runner) followMoves(ctx context.Context, f *model.Friend, siteMoved, feedMoved string) {
+	oldFeed := f.Feed
+	if feedMoved != "" && feedMoved != oldFeed {
+		logx.Infof("[%s|%s] 订阅已永久迁移：%s -> %s", f.Name, hostOf(f.Link), oldFeed, feedMoved)
+		f.Feed = feedMoved
+	}
+	link := siteMoved
+	if link == "" && feedMoved != "" {
+		link = r.movedLink(ctx, f.Link, oldFeed, feedMoved)
+	}
+	if link != "" && link != f.Link {
+		logx.Infof("[%s] 友链已迁移：%s -> %s", f.Name, f.Link, link)
+		r.migrate(ctx, f.Link, link)
+		f.Link = link
+	}
+	if f.Feed != oldFeed {
+		r.updateCachedFeed(ctx, f.Link, f.Feed)
+	}
+}
+
+// movedLink 根据订阅的迁移推断站点的新链接，仅在旧订阅与站点同主机时推断：
+// 订阅只是同主机升级协议时沿用新协议；迁到其他主机时探测首页，首页同样永久重定向才采用其新地址。
+// 无法确认站点迁移时返回空串。
+func (r *Runner) movedLink(ctx context.Context, link, from, to string) string {
+	lu, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	fu, err := url.Parse(from)
+	if err != nil {
+		return ""
+	}
+	tu, err := url.Parse(to)
+	if err != nil || tu.Host == "" || !strings.EqualFold(lu.Host, fu.Host) {
+		return ""
+	}
+	if strings.EqualFold(fu.Host, tu.Host) {
+		if fu.Scheme == tu.Scheme {
+			return ""
+		}
+		nu := *lu
+		nu.Scheme = tu.Scheme
+		return nu.String()
+	}
+	resp, err := r.fetch.Get(ctx, link)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	moved, ok := fetch.MovedTo(resp)
+	if !ok {
+		return ""
+	}
+	mu, err := url.Parse(moved)
+	if err != nil || mu.Host == "" {
+		return ""
+	}
+	// 首页路径未变（含补上的末尾斜杠）时只替换协议与主机，保持链接原有写法
+	if strings.TrimSuffix(mu.Path, "/") == strings.TrimSuffix(lu.Path, "/") && mu.RawQuery == lu.RawQuery {
+		nu := *lu
+		nu.Scheme, nu.Host = mu.Scheme, mu.Host
+		return nu.String()
+	}
+	return moved
+}
+
+// aliases 返回历史链接到当前链接的映射：正常模式读数据库，极简模式读状态文件。
+func (r *Runner) aliases(ctx context.Context) map[string]string {
 	if r.buf != nil {
-		r.buf.AddFriend(f)
-	} else {
-		if err := r.store.UpsertFriend(ctx, f); err != nil {
-			logx.Warnf("写入朋友失败：%v", err)
+		if r.state != nil {
+			return r.state.aliases()
 		}
+		return nil
 	}
-	// 解析文章条目
-	items, err := feeds.ParseFeed(ctx, r.fetch, feedURL, r.cfg.MaxPostsNum)
+	if r.store == nil {
+		return nil
+	}
+	m, err := r.store.FriendAliases(ctx)
 	if err != nil {
-		logx.Warnf("[%s|%s] 解析订阅失败：%v", sf.Name, host, err)
+		logx.Warnf("读取友链别名失败：%v", err)
+		return nil
+	}
+	return m
+}
+
+// migrate 将朋友的已有数据从 from 改挂到 to 下并记录别名。
+func (r *Runner) migrate(ctx context.Context, from, to string) {
+	if r.buf != nil {
+		r.buf.relink(from, to)
+		if r.state != nil {
+			r.state.migrate(from, to)
+		}
+		return
+	}
+	if err := r.store.MigrateFriend(ctx, from, to); err != nil {
+		logx.Warnf("迁移朋友失败：%v", err)
+	}
+}
+
+// prevRepairs 返回朋友上次记录的订阅修复项：正常模式读数据库，极简模式读状态文件。
+func (r *Runner) prevRepairs(ctx context.Context, link string) []string {
+	if r.buf != nil {
+		if r.state != nil {
+			return r.state.repairs(link)
+		}
+		return nil
+	}
+	if r.store == nil {
+		return nil
+	}
+	repaired, err := r.store.FriendRepaired(ctx, link)
+	if err != nil {
+		logx.Warnf("读取订阅修复记录失败：%v", err)
+		return nil
+	}
+	if repaired == "" {
+		return nil
+	}
+	return strings.Split(repaired, ",")
+}
+
+// updateCachedFeed 将订阅缓存中朋友的订阅地址改为迁移后的地址（保留命中策略）。
+func (r *Runner) updateCachedFeed(ctx context.Context, link, feed string) {
+	if r.buf != nil || r.store == nil || r.cfg.FeedCacheTTL < 0 {
+		return
+	}
+	c, ok, err := r.store.GetFeedCache(ctx, link)
+	if err != nil || !ok {
 		return
 	}
-	logx.Infof("[%s|%s] 文章解析完成：%d", sf.Name, host, len(items))
+	c.Feed = feed
+	if err := r.store.PutFeedCache(ctx, c); err != nil {
+		logx.Warnf("写入订阅缓存失败：%v", err)
+	}
+}
+
+// collectPosts 解析订阅并写入文章，返回解析结果（含修复与订阅迁移）；订阅未变化（304）时保留已有文章与修复项：
+// 正常模式下文章已在库中，极简模式下从状态文件恢复。
+func (r *Runner) collectPosts(ctx context.Context, sf config.StaticFriend, feedURL, label string) (feeds.FeedResult, error) {
+	host := hostOf(sf.Link)
+	res, err := feeds.ParseFeedDetail(ctx, r.fetch, feedURL, r.cfg.MaxPostsNum)
+	if errors.Is(err, feeds.ErrNotModified) {
+		if r.buf != nil {
+			prev := []model.Post(nil)
+			if r.state != nil {
+				prev = r.state.posts(sf.Link)
+			}
+			r.buf.AddPosts(prev)
+		}
+		logx.Infof("[%s|%s] %s未变化，保留已有文章", sf.Name, host, label)
+		return feeds.FeedResult{Repairs: r.prevRepairs(ctx, sf.Link)}, nil
+	}
+	if err != nil {
+		return feeds.FeedResult{}, err
+	}
+	if r.state != nil {
+		r.state.setRepairs(sf.Link, res.Repairs)
+	}
+	if len(res.Repairs) > 0 {
+		logx.Warnf("[%s|%s] %s格式有误，已修复后解析：%s", sf.Name, host, label, strings.Join(res.Repairs, ","))
+	}
+	logx.Infof("[%s|%s] 文章解析完成：%d", sf.Name, host, len(res.Items))
+	r.savePosts(ctx, sf, res.Items)
+	return res, nil
+}
+
+// friendError 将发现/抓取错误转为朋友的错误描述；被 robots.txt 禁止时统一记为固定文案。
+func friendError(err error) string {
+	if errors.Is(err, fetch.ErrDisallowed) {
+		return fetch.ErrDisallowed.Error()
+	}
+	return err.Error()
+}
+
+// cachedFeed 返回未过期的缓存订阅地址；极简模式或关闭缓存时总是返回 false。
+func (r *Runner) cachedFeed(ctx context.Context, link string) (string, bool) {
+	if r.buf != nil || r.store == nil || r.cfg.FeedCacheTTL < 0 {
+		return "", false
+	}
+	c, ok, err := r.store.GetFeedCache(ctx, link)
+	if err != nil {
+		logx.Warnf("读取订阅缓存失败：%v", err)
+		return "", false
+	}
+	if !ok || c.Feed == "" {
+		return "", false
+	}
+	if time.Since(c.DiscoveredAt) > r.cfg.FeedCacheTTL {
+		logx.Debugf("订阅缓存已过期：%s（发现于 %s）", link, c.DiscoveredAt.Format(time.RFC3339))
+		return "", false
+	}
+	return c.Feed, true
+}
+
+// rememberFeed 记录本次发现的订阅地址及命中策略，供后续运行复用。
+func (r *Runner) rememberFeed(ctx context.Context, link string, tr *feeds.Trace) {
+	if r.buf != nil || r.store == nil || r.cfg.FeedCacheTTL < 0 {
+		return
+	}
+	c := store.FeedCache{Link: link, Feed: tr.Feed, DiscoveredAt: time.Now()}
+	if a, ok := tr.Winner(); ok {
+		c.Strategy = a.Source
+	}
+	if err := r.store.PutFeedCache(ctx, c); err != nil {
+		logx.Warnf("写入订阅缓存失败：%v", err)
+	}
+}
+
+// certWarnBefore 为证书临近过期的告警阈值。
+const certWarnBefore = 14 * 24 * time.Hour
+
+// noteCert 记录朋友站点（优先友链主机，其次订阅主机）的证书过期时间，临近过期时告警。
+// 仅当抓取器实现 fetch.CertObserver 时生效。
+func (r *Runner) noteCert(f *model.Friend) {
+	obs, ok := r.fetch.(fetch.CertObserver)
+	if !ok {
+		return
+	}
+	for _, raw := range []string{f.Link, f.Feed} {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		host := u.Hostname()
+		if t, ok := obs.CertExpiry(host); ok {
+			f.CertExpiresAt = &t
+			if time.Until(t) < certWarnBefore {
+				logx.Warnf("[%s|%s] 证书将于 %s 过期", f.Name, host, t.Format(time.RFC3339))
+			}
+			return
+		}
+	}
+}
+
+// saveFriend 写入朋友：极简模式进内存，正常模式落库。
+func (r *Runner) saveFriend(ctx context.Context, f model.Friend) {
+	r.noteCert(&f)
+	if r.buf != nil {
+		r.buf.AddFriend(f)
+		return
+	}
+	if err := r.store.UpsertFriend(ctx, f); err != nil {
+		logx.Warnf("写入朋友失败：%v", err)
+	}
+}
+
+// savePosts 将订阅条目转换为文章并写入；极简模式下同时记入状态以备 304 时恢复。
+func (r *Runner) savePosts(ctx context.Context, sf config.StaticFriend, items []feeds.Item) {
+	var kept []model.Post
 	for _, it := range items {
 		p := model.Post{
-			Title:     it.Title,
-			Created:   it.Created,
-			Updated:   it.Updated,
-			Link:      it.Link,
-			Author:    it.Author,
-			Avatar:    sf.Avatar,
-			Rule:      "feed",
-			CreatedAt: time.Now(),
+			Title:      it.Title,
+			Created:    it.Created,
+			Updated:    it.Updated,
+			Link:       it.Link,
+			Author:     it.Author,
+			Avatar:     sf.Avatar,
+			Rule:       "feed",
+			FriendLink: sf.Link,
+			CreatedAt:  time.Now(),
 		}
 		if r.buf != nil {
 			r.buf.AddPost(p)
+			kept = append(kept, p)
 		} else {
 			if err := r.store.UpsertPost(ctx, p); err != nil {
 				logx.Warnf("写入文章失败：%v", err)
 			}
 		}
 	}
+	if r.buf != nil && r.state != nil {
+		r.state.setPosts(sf.Link, kept)
+	}
 }
 
 // dedup 按 link 去重。
@@ -165,6 +451,21 @@ func dedup(in []config.StaticFriend) []config.StaticFriend {
 	return out
 }
 
+// canonicalize 返回将已迁移的旧链接替换为当前链接后的朋友列表副本。
+func canonicalize(in []config.StaticFriend, aliases map[string]string) []config.StaticFriend {
+	if len(aliases) == 0 {
+		return in
+	}
+	out := make([]config.StaticFriend, len(in))
+	for i, f := range in {
+		if to, ok := aliases[f.Link]; ok {
+			f.Link = to
+		}
+		out[i] = f
+	}
+	return out
+}
+
 // mergeDedup 合并两个朋友切片并按 link 去重。
 func mergeDedup(base []config.StaticFriend, add []config.StaticFriend) []config.StaticFriend {
 	m := map[string]config.StaticFriend{}
@@ -212,6 +513,11 @@ func hostOf(raw string) string {
 	return s
 }
 
+// SourceResults 返回最近一轮各友链来源的解析结果（含失败原因）。
+func (r *Runner) SourceResults() []sources.Result {
+	return r.sources
+}
+
 // BufferData 返回极简模式下收集的内存数据（朋友、文章）。
 func (r *Runner) BufferData() ([]model.Friend, []model.Post) {
 	if r == nil || r.buf == nil {
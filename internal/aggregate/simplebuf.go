@@ -50,6 +50,35 @@ func (b *SimpleBuffer) AddPosts(list []model.Post) {
 	b.mu.Unlock()
 }
 
+// relink 将朋友 from 的记录及其文章改挂到新链接 to 下（朋友站点迁移）。
+func (b *SimpleBuffer) relink(from, to string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if f, ok := b.friends[from]; ok {
+		delete(b.friends, from)
+		f.Link = to
+		b.friends[to] = f
+	}
+	for k, p := range b.posts {
+		if p.FriendLink == from {
+			p.FriendLink = to
+			b.posts[k] = p
+		}
+	}
+}
+
+// relinkPosts 返回将所属朋友从 from 改为 to 后的文章副本。
+func relinkPosts(ps []model.Post, from, to string) []model.Post {
+	out := make([]model.Post, len(ps))
+	for i, p := range ps {
+		if p.FriendLink == from {
+			p.FriendLink = to
+		}
+		out[i] = p
+	}
+	return out
+}
+
 // Snapshot 返回副本：
 // - friends 按名字排序
 // - posts 按创建时间倒序
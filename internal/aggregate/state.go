@@ -0,0 +1,157 @@
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"go-circle-of-friends/internal/model"
+)
+
+// SimpleState 为极简模式的跨运行状态（JSON 文件）：
+// - validators：订阅的 ETag/Last-Modified（实现 fetch.ValidatorStore）
+// - posts：每位朋友上次解析出的文章，订阅返回 304 时据此恢复
+// - aliases：朋友的历史链接到当前链接的映射（站点永久重定向后记录）
+// - repairs：每位朋友上次解析订阅时的修复项，订阅返回 304 时据此保留
+type SimpleState struct {
+	mu   sync.Mutex
+	path string
+	data simpleStateData
+}
+
+type simpleStateData struct {
+	Validators map[string]model.Validator `json:"validators"` // key: feed url
+	Posts      map[string][]model.Post    `json:"posts"`      // key: friend link
+	Aliases    map[string]string          `json:"aliases,omitempty"`
+	Repairs    map[string][]string        `json:"repairs,omitempty"` // key: friend link
+}
+
+// LoadSimpleState 读取状态文件；文件不存在时返回空状态。
+func LoadSimpleState(path string) (*SimpleState, error) {
+	s := &SimpleState{path: path}
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("read state %s: %w", path, err)
+	default:
+		if err := json.Unmarshal(b, &s.data); err != nil {
+			return nil, fmt.Errorf("decode state %s: %w", path, err)
+		}
+	}
+	if s.data.Validators == nil {
+		s.data.Validators = map[string]model.Validator{}
+	}
+	if s.data.Posts == nil {
+		s.data.Posts = map[string][]model.Post{}
+	}
+	if s.data.Aliases == nil {
+		s.data.Aliases = map[string]string{}
+	}
+	if s.data.Repairs == nil {
+		s.data.Repairs = map[string][]string{}
+	}
+	return s, nil
+}
+
+// Save 将状态写回文件（先写临时文件再改名，避免中途失败留下半截文件）。
+func (s *SimpleState) Save() error {
+	s.mu.Lock()
+	b, err := json.Marshal(s.data)
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("write state %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("rename state %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// LoadValidator 实现 fetch.ValidatorStore。
+func (s *SimpleState) LoadValidator(_ context.Context, url string) (model.Validator, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data.Validators[url]
+	return v, ok, nil
+}
+
+// SaveValidator 实现 fetch.ValidatorStore（仅写内存，Save 时落盘）。
+func (s *SimpleState) SaveValidator(_ context.Context, url string, v model.Validator) error {
+	s.mu.Lock()
+	s.data.Validators[url] = v
+	s.mu.Unlock()
+	return nil
+}
+
+// posts 返回朋友上次解析出的文章。
+func (s *SimpleState) posts(link string) []model.Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Posts[link]
+}
+
+// setPosts 记录朋友本次解析出的文章。
+func (s *SimpleState) setPosts(link string, ps []model.Post) {
+	s.mu.Lock()
+	s.data.Posts[link] = ps
+	s.mu.Unlock()
+}
+
+// repairs 返回朋友上次解析订阅时的修复项。
+func (s *SimpleState) repairs(link string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Repairs[link]
+}
+
+// setRepairs 记录朋友本次解析订阅时的修复项（无修复时删除记录）。
+func (s *SimpleState) setRepairs(link string, repairs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(repairs) == 0 {
+		delete(s.data.Repairs, link)
+		return
+	}
+	s.data.Repairs[link] = repairs
+}
+
+// aliases 返回历史链接到当前链接的映射副本。
+func (s *SimpleState) aliases() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]string, len(s.data.Aliases))
+	for k, v := range s.data.Aliases {
+		out[k] = v
+	}
+	return out
+}
+
+// migrate 将朋友从 from 迁移到 to：记录别名并将已保存的文章改挂到新链接下。
+func (s *SimpleState) migrate(from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data.Aliases, to)
+	for k, v := range s.data.Aliases {
+		if v == from {
+			s.data.Aliases[k] = to
+		}
+	}
+	s.data.Aliases[from] = to
+	if ps, ok := s.data.Posts[from]; ok {
+		delete(s.data.Posts, from)
+		s.data.Posts[to] = relinkPosts(ps, from, to)
+	}
+	if rs, ok := s.data.Repairs[from]; ok {
+		delete(s.data.Repairs, from)
+		s.data.Repairs[to] = rs
+	}
+}
@@ -0,0 +1,219 @@
+// 包 api 提供只读 HTTP 接口，基于 SQLite 存储对外暴露聚合结果：
+// - /all：统计 + 朋友 + 文章（分页）
+// - /friends、/posts：列表查询（分页/排序/按朋友过滤）
+// - /randomfriend、/randompost：随机朋友/文章
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-circle-of-friends/internal/logx"
+	"go-circle-of-friends/internal/model"
+	"go-circle-of-friends/internal/store"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 200
+	maxRandomNum    = 50
+)
+
+// Server 为 HTTP 接口服务，持有只读使用的存储。
+type Server struct {
+	store *store.SQLite
+	mux   *http.ServeMux
+}
+
+// New 创建 Server 并注册路由。
+func New(s *store.SQLite) *Server {
+	srv := &Server{store: s, mux: http.NewServeMux()}
+	srv.mux.HandleFunc("/all", srv.handleAll)
+	srv.mux.HandleFunc("/friends", srv.handleFriends)
+	srv.mux.HandleFunc("/posts", srv.handlePosts)
+	srv.mux.HandleFunc("/randomfriend", srv.handleRandomFriend)
+	srv.mux.HandleFunc("/randompost", srv.handleRandomPost)
+	return srv
+}
+
+// ServeHTTP 实现 http.Handler：仅允许 GET/HEAD，并开放跨域以便前端直接调用。
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// PostsPage 为分页文章响应。
+type PostsPage struct {
+	Total int          `json:"total"`
+	Page  int          `json:"page"`
+	Size  int          `json:"size"`
+	Posts []model.Post `json:"posts"`
+}
+
+// FriendsPage 为分页朋友响应。
+type FriendsPage struct {
+	Total   int            `json:"total"`
+	Page    int            `json:"page"`
+	Size    int            `json:"size"`
+	Friends []model.Friend `json:"friends"`
+}
+
+// AllResponse 为 /all 的响应：统计 + 全部朋友 + 分页文章。
+type AllResponse struct {
+	Stats   model.Stats    `json:"stats"`
+	Friends []model.Friend `json:"friends"`
+	PostsPage
+}
+
+func (s *Server) handleAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	st, err := s.store.Stats(ctx)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	fr, err := s.store.ListFriends(ctx)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	pp, err := s.queryPosts(r)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, AllResponse{Stats: st, Friends: nonNil(fr), PostsPage: pp})
+}
+
+func (s *Server) handleFriends(w http.ResponseWriter, r *http.Request) {
+	fr, err := s.store.ListFriends(r.Context())
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	page, size := pageParams(r)
+	total := len(fr)
+	start := min((page-1)*size, total)
+	end := min(start+size, total)
+	writeJSON(w, http.StatusOK, FriendsPage{Total: total, Page: page, Size: size, Friends: nonNil(fr[start:end])})
+}
+
+func (s *Server) handlePosts(w http.ResponseWriter, r *http.Request) {
+	pp, err := s.queryPosts(r)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pp)
+}
+
+func (s *Server) handleRandomFriend(w http.ResponseWriter, r *http.Request) {
+	fr, err := s.store.RandomFriends(r.Context(), numParam(r))
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(fr))
+}
+
+func (s *Server) handleRandomPost(w http.ResponseWriter, r *http.Request) {
+	ps, err := s.store.RandomPosts(r.Context(), numParam(r))
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(ps))
+}
+
+// queryPosts 解析分页/排序/过滤参数并查询文章。
+func (s *Server) queryPosts(r *http.Request) (PostsPage, error) {
+	page, size := pageParams(r)
+	q := r.URL.Query()
+	sort := strings.ToLower(strings.TrimSpace(q.Get("sort")))
+	if sort != "updated" {
+		sort = "created"
+	}
+	ps, total, err := s.store.QueryPosts(r.Context(), store.PostQuery{
+		Offset: (page - 1) * size,
+		Limit:  size,
+		Sort:   sort,
+		Friend: strings.TrimSpace(q.Get("friend")),
+	})
+	if err != nil {
+		return PostsPage{}, err
+	}
+	return PostsPage{Total: total, Page: page, Size: size, Posts: nonNil(ps)}, nil
+}
+
+// fail 记录内部错误并返回 500，避免将存储细节暴露给调用方。
+func (s *Server) fail(w http.ResponseWriter, err error) {
+	logx.Errorf("接口查询失败：%v", err)
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+// pageParams 解析 page（从 1 开始）与 size（默认 20，最大 200）。
+func pageParams(r *http.Request) (int, int) {
+	q := r.URL.Query()
+	page := atoiOr(q.Get("page"), 1)
+	if page < 1 {
+		page = 1
+	}
+	size := atoiOr(q.Get("size"), defaultPageSize)
+	if size < 1 {
+		size = defaultPageSize
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+	return page, size
+}
+
+// numParam 解析随机接口的 num（默认 1，最大 50）。
+func numParam(r *http.Request) int {
+	n := atoiOr(r.URL.Query().Get("num"), 1)
+	if n < 1 {
+		n = 1
+	}
+	if n > maxRandomNum {
+		n = maxRandomNum
+	}
+	return n
+}
+
+func atoiOr(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// nonNil 保证空列表编码为 [] 而非 null，便于前端处理。
+func nonNil[T any](in []T) []T {
+	if in == nil {
+		return []T{}
+	}
+	return in
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(v); err != nil {
+		logx.Warnf("写入响应失败：%v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,29 +20,76 @@ type Config struct {
 	MaxPostsNum      int            `yaml:"MAX_POSTS_NUM"`
 	OutdateCleanDays int            `yaml:"OUTDATE_CLEAN"`
 	SimpleMode       bool           `yaml:"SIMPLE_MODE"`
+	StateFile        string         `yaml:"STATE_FILE"` // 极简模式的跨运行状态（条件请求校验值与文章）
 	ResetOnStart     bool           `yaml:"RESET_ON_START"`
+	FeedCacheTTL     time.Duration  `yaml:"FEED_CACHE_TTL"` // 订阅发现缓存有效期（仅正常模式），负数关闭缓存
 	Database         Database       `yaml:"DATABASE"`
 	Concurrency      Concurrency    `yaml:"CONCURRENCY"`
 	Proxy            Proxy          `yaml:"PROXY"`
+	HTTP             HTTP           `yaml:"HTTP"`
+	DNS              DNS            `yaml:"DNS"`
+	Schedule         Schedule       `yaml:"SCHEDULE"`
 	LogLevel         string         `yaml:"LOG_LEVEL"`
 	LogFormat        string         `yaml:"LOG_FORMAT"` // text|json|pretty
 	LogLocale        string         `yaml:"LOG_LOCALE"` // zh-CN|en
 	LogColor         string         `yaml:"LOG_COLOR"`  // auto|always|never
+
+	// AllowPrivateNetworks 允许抓取解析到私有/回环地址的站点（友链列表来自用户输入，默认拒绝）
+	AllowPrivateNetworks bool `yaml:"ALLOW_PRIVATE_NETWORKS"`
 }
 
 type LinkSource struct {
-	// Type：来源类型，当前支持 page（友链页按选择器解析）
-	Type  string `yaml:"type"` // page|json (page rules are WIP; we support json later)
-	URL   string `yaml:"url"`
+	// Type：来源类型，对应 sources 包中注册的来源：page（友链页按选择器解析，默认）、
+	// json 或 yaml（友链数据文件）、opml（订阅列表）
+	Type string `yaml:"type"`
+	URL  string `yaml:"url"`
+	// Path：json/yaml/opml 的本地文件（如 source/_data/link.yml），与 url 二选一
+	Path string `yaml:"path"`
+	// Theme：page 为 rules.yaml 中的预设名；json/yaml 为内置格式（fcircle|butterfly|list），为空时自动识别
 	Theme string `yaml:"theme"`
+	// Mapping：json/yaml 的字段映射，覆盖内置格式中的同名字段
+	Mapping JSONMapping `yaml:"mapping"`
+	// Options：其他来源类型各自的配置块，由来源通过 DecodeOptions 解析
+	Options yaml.Node `yaml:"options"`
+}
+
+// DecodeOptions 将 options 块解码到 v；未配置时保持 v 不变。
+func (s LinkSource) DecodeOptions(v any) error {
+	if s.Options.Kind == 0 {
+		return nil
+	}
+	if err := s.Options.Decode(v); err != nil {
+		return fmt.Errorf("decode %s options: %w", s.Type, err)
+	}
+	return nil
+}
+
+// Location 返回来源位置（本地路径优先），用于日志。
+func (s LinkSource) Location() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	return s.URL
+}
+
+// JSONMapping 为 JSON 友链列表的字段映射（JSONPath 风格，支持 "||" 回退）：
+// items 从根定位朋友条目（如 $.friends[*]、$[*].link_list[*]），其余字段相对于单个条目（如 name、[0]）。
+type JSONMapping struct {
+	Items  string `yaml:"items"`
+	Name   string `yaml:"name"`
+	Link   string `yaml:"link"`
+	Avatar string `yaml:"avatar"`
+	Feed   string `yaml:"feed"`
 }
 
 type StaticFriend struct {
 	// FeedSuffix：可选订阅后缀（如 /atom.xml /feed），用于提升发现命中率
+	// Feed：已知的订阅地址，设置后跳过订阅发现
 	Name       string `yaml:"name"`
 	Link       string `yaml:"link"`
 	Avatar     string `yaml:"avatar"`
 	FeedSuffix string `yaml:"feed_suffix"`
+	Feed       string `yaml:"feed"`
 }
 
 type Database struct {
@@ -51,11 +100,65 @@ type Database struct {
 type Concurrency struct {
 	Fetch int `yaml:"fetch"`
 	Retry int `yaml:"retry"`
+	Probe int `yaml:"probe"` // 单个站点并发探测的候选订阅数
+	// 限速：同一主机并发上限（负数不限制）、同一主机请求最小间隔、全局每秒请求数（0 不限制）
+	PerHost   int           `yaml:"per_host"`
+	HostDelay time.Duration `yaml:"host_delay"`
+	RPS       float64       `yaml:"rps"`
 }
 
+// Proxy 为代理配置：http/https 为按协议的默认代理，rules 按主机路由（先匹配者生效）。
+// 代理地址支持 http/https/socks5/socks5h 及 user:pass@ 认证。
 type Proxy struct {
-	HTTP  string `yaml:"http"`
-	HTTPS string `yaml:"https"`
+	HTTP  string      `yaml:"http"`
+	HTTPS string      `yaml:"https"`
+	Rules []ProxyRule `yaml:"rules"`
+}
+
+// ProxyRule 将匹配 suffix（主机后缀）或 regex（主机名正则）的请求路由到 proxy，
+// proxy 取 "direct" 表示直连。
+type ProxyRule struct {
+	Suffix string `yaml:"suffix"`
+	Regex  string `yaml:"regex"`
+	Proxy  string `yaml:"proxy"`
+}
+
+// HTTP 为请求配置：全局 UA/请求头，以及按主机（含子域名）覆盖的 UA/请求头/Cookie。
+type HTTP struct {
+	UserAgent string            `yaml:"user_agent"` // 为空时使用环境变量 COF_UA 或内置浏览器 UA
+	Headers   map[string]string `yaml:"headers"`
+	Hosts     []HostProfile     `yaml:"hosts"`
+	// 响应体上限（MB，按解压后大小计）与重定向上限，0 使用默认值（页面 4、订阅 16、重定向 10）
+	MaxPageMB    int `yaml:"max_page_mb"`
+	MaxFeedMB    int `yaml:"max_feed_mb"`
+	MaxRedirects int `yaml:"max_redirects"`
+	// RobotsTxt 开启后遵守各站点 robots.txt（按主机缓存），被禁止的朋友记为 "blocked by robots.txt"
+	RobotsTxt bool `yaml:"robots_txt"`
+}
+
+// HostProfile 为单个主机的请求配置，越具体的主机越后应用。
+type HostProfile struct {
+	Host      string            `yaml:"host"` // 如 example.com，同时匹配其子域名
+	UserAgent string            `yaml:"user_agent"`
+	Headers   map[string]string `yaml:"headers"`
+	Cookies   map[string]string `yaml:"cookies"`
+	// TLS：额外信任的 CA 证书（PEM 文件，用于自签名站点）；跳过证书校验需显式开启
+	CAFile             string `yaml:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// DNS 为解析配置：resolver 为自定义 DNS 服务器（如 1.1.1.1:53），hosts 为主机名到 IP 的覆盖表。
+type DNS struct {
+	Resolver string            `yaml:"resolver"`
+	Hosts    map[string]string `yaml:"hosts"`
+}
+
+// Schedule 为常驻模式（-daemon）的调度配置：cron 优先于 interval。
+type Schedule struct {
+	Interval   time.Duration `yaml:"interval"`     // 如 30m、6h
+	Cron       string        `yaml:"cron"`         // 5 段 cron 表达式，如 "0 */2 * * *"
+	Jitter     time.Duration `yaml:"jitter"`       // 每次触发前的随机延迟上限
+	RunOnStart bool          `yaml:"run_on_start"` // 启动后立即执行一轮
 }
 
 func Load(path string) (*Config, error) {
@@ -81,6 +184,12 @@ func Load(path string) (*Config, error) {
 
 func (c *Config) Validate() error {
 	// Validate 负责合法性检查与默认值设置，避免在业务层分散判空逻辑。
+	// LINK 条目的类型与各自配置由 sources 包按注册表校验（见 sources.Validate）
+	for i := range c.LinkSources {
+		if c.LinkSources[i].Type == "" {
+			c.LinkSources[i].Type = "page"
+		}
+	}
 	if c.MaxPostsNum < 0 {
 		return errors.New("MAX_POSTS_NUM must be >= 0")
 	}
@@ -99,9 +208,35 @@ func (c *Config) Validate() error {
 	if c.Concurrency.Fetch <= 0 {
 		c.Concurrency.Fetch = 8
 	}
+	if c.Concurrency.Probe <= 0 {
+		c.Concurrency.Probe = 4
+	}
 	if c.Concurrency.Retry < 0 {
 		c.Concurrency.Retry = 2
 	}
+	if c.Concurrency.PerHost == 0 {
+		c.Concurrency.PerHost = 4
+	}
+	if c.Concurrency.HostDelay < 0 || c.Concurrency.RPS < 0 {
+		return errors.New("CONCURRENCY.host_delay and CONCURRENCY.rps must be >= 0")
+	}
+	if c.StateFile == "" {
+		c.StateFile = "./state.json"
+	}
+	if c.FeedCacheTTL == 0 {
+		c.FeedCacheTTL = 7 * 24 * time.Hour
+	}
+	if c.HTTP.MaxPageMB < 0 || c.HTTP.MaxFeedMB < 0 || c.HTTP.MaxRedirects < 0 {
+		return errors.New("HTTP.max_page_mb, HTTP.max_feed_mb and HTTP.max_redirects must be >= 0")
+	}
+	for i, h := range c.HTTP.Hosts {
+		if strings.TrimSpace(h.Host) == "" {
+			return fmt.Errorf("HTTP.hosts[%d].host is required", i)
+		}
+	}
+	if c.Schedule.Interval < 0 || c.Schedule.Jitter < 0 {
+		return errors.New("SCHEDULE.interval and SCHEDULE.jitter must be >= 0")
+	}
 	if c.LogFormat == "" {
 		c.LogFormat = "pretty"
 	}
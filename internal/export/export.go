@@ -1,4 +1,5 @@
-// 包 export 负责极简模式导出：将库中数据写为 data.json。
+// 包 export 负责导出 data.json：正常模式读取数据库（ToJSON），极简模式使用内存数据（ToJSONData）；
+// 另可将朋友的订阅导出为 OPML（ToOPML/ToOPMLData）。
 package export
 
 import (
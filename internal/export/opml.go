@@ -0,0 +1,80 @@
+package export
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+
+	"go-circle-of-friends/internal/model"
+	"go-circle-of-friends/internal/store"
+)
+
+// opml 为 OPML 2.0 文档（仅包含导出所需字段）。
+type opml struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Text    string `xml:"text,attr"`
+	Title   string `xml:"title,attr"`
+	Type    string `xml:"type,attr"`
+	XMLURL  string `xml:"xmlUrl,attr"`
+	HTMLURL string `xml:"htmlUrl,attr,omitempty"`
+}
+
+// ToOPML 从数据库读取朋友并写出 OPML 订阅列表（见 ToOPMLData）。
+func ToOPML(ctx context.Context, s *store.SQLite, path string) error {
+	friends, err := s.ListFriends(ctx)
+	if err != nil {
+		return fmt.Errorf("list friends: %w", err)
+	}
+	return ToOPMLData(ctx, friends, path)
+}
+
+// ToOPMLData 将存活（无错误）且已发现订阅的朋友写成 OPML 2.0，可直接导入 RSS 阅读器订阅整个朋友圈。
+func ToOPMLData(_ context.Context, friends []model.Friend, path string) error {
+	doc := opml{
+		Version: "2.0",
+		Head:    opmlHead{Title: "Circle of Friends", DateCreated: time.Now().UTC().Format(time.RFC1123Z)},
+	}
+	for _, f := range friends {
+		if f.Error != "" || f.Feed == "" {
+			continue
+		}
+		name := f.Name
+		if name == "" {
+			name = f.Link
+		}
+		doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{
+			Text:    name,
+			Title:   name,
+			Type:    "rss",
+			XMLURL:  f.Feed,
+			HTMLURL: f.Link,
+		})
+	}
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode opml: %w", err)
+	}
+	b = append([]byte(xml.Header), b...)
+	b = append(b, '\n')
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
@@ -1,15 +1,17 @@
 // 包 feeds 负责订阅发现与解析：
 // - DiscoverFeed：基于常见路径与 HTML <link> 自动发现订阅
-// - ParseFeed：使用 gofeed 解析 RSS/Atom/JSON Feed 并归一化
+// - ParseFeed：使用 gofeed 解析 RSS/Atom/JSON Feed 并归一化（失败时修复常见缺陷后重试）
 package feeds
 
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"go-circle-of-friends/internal/fetch"
@@ -19,27 +21,124 @@ import (
 	"github.com/mmcdole/gofeed"
 )
 
+// DefaultProbeConcurrency 为单个站点同时探测的候选数上限。
+const DefaultProbeConcurrency = 4
+
+// Option 为发现流程的可选参数。
+type Option func(*options)
+
+type options struct {
+	concurrency int
+}
+
+// WithConcurrency 设置单个站点同时探测的候选数（<=0 使用默认值，1 即串行）。
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+func buildOptions(opts []Option) options {
+	o := options{concurrency: DefaultProbeConcurrency}
+	for _, fn := range opts {
+		fn(&o)
+	}
+	return o
+}
+
 // DiscoverFeed 尝试常见端点与 HTML <link> 以发现订阅地址。
-func DiscoverFeed(ctx context.Context, cl *fetch.Client, site string, feedSuffix string) (string, error) {
+func DiscoverFeed(ctx context.Context, cl fetch.Fetcher, site string, feedSuffix string, opts ...Option) (string, error) {
+	u, _, err := DiscoverFeedTrace(ctx, cl, site, feedSuffix, opts...)
+	return u, err
+}
+
+// DiscoverFeedTrace 与 DiscoverFeed 相同，但额外返回完整探测轨迹（用于 probe 调试）。
+func DiscoverFeedTrace(ctx context.Context, cl fetch.Fetcher, site string, feedSuffix string, opts ...Option) (string, *Trace, error) {
+	tr := &Trace{Site: site}
+	u, err := discover(ctx, cl, site, feedSuffix, buildOptions(opts), tr)
+	tr.Feed = u
+	return u, tr, err
+}
+
+// Trace 记录一次订阅发现的全过程。
+type Trace struct {
+	Site     string
+	Feed     string    // 最终命中的订阅，未命中为空
+	Attempts []Attempt // 按候选优先级排列
+	LinkTags []LinkTag // 首页中声明的 <link>（仅在回退解析 HTML 时填充）
+	HTMLErr  string    // 抓取/解析首页失败的原因
+	// SiteMoved 为首页永久重定向（301/308）后的最终地址（仅在回退解析 HTML 时检测）
+	SiteMoved string
+}
+
+// Attempt 为单个候选的探测结果。
+type Attempt struct {
+	URL         string
+	Source      string // 候选来源，见 Source* 常量
+	Status      int    // HTTP 状态码，请求未完成时为 0
+	ContentType string
+	Verdict     string // 嗅探结论，见 verdict* 常量
+	OK          bool
+	Err         string
+	Duration    time.Duration
+}
+
+// LinkTag 为 HTML 中的 <link> 声明。
+type LinkTag struct {
+	Rel  string
+	Type string
+	Href string // 已绝对化
+}
+
+// 候选来源（即命中订阅所用的发现策略）
+const (
+	SourceFeedSuffix = "feed_suffix" // 朋友配置的 feed_suffix
+	SourcePath       = "path"        // 常见订阅路径
+	SourceLink       = "link"        // 首页 <link rel=alternate> 声明
+)
+
+// 嗅探结论
+const (
+	verdictError       = "request-error"
+	verdictFeedType    = "feed-content-type"
+	verdictJSONFeed    = "jsonfeed"
+	verdictJSONNotFeed = "json-not-feed"
+	verdictSniffXML    = "sniffed-xml-feed"
+	verdictSniffJSON   = "sniffed-jsonfeed"
+	verdictNotFeed     = "not-a-feed"
+	verdictCancelled   = "cancelled" // 更高优先级候选已命中，本次探测被取消
+	verdictRobots      = "robots-disallowed"
+)
+
+// candidate 为一个候选订阅地址及其来源。
+type candidate struct {
+	url    string
+	source string
+}
+
+// candidates 返回按优先级排列的候选订阅地址。
+func candidates(site string, feedSuffix string) []candidate {
+	var out []candidate
 	// 若提供了 feedSuffix，则优先尝试
-	candidates := []string{}
 	if feedSuffix != "" {
-		candidates = append(candidates,
-			// 同时尝试根路径与基路径拼接两种语义
-			joinURL(site, feedSuffix),
-			joinURLDir(site, feedSuffix),
+		// 同时尝试根路径与基路径拼接两种语义
+		out = append(out,
+			candidate{joinURL(site, feedSuffix), SourceFeedSuffix},
+			candidate{joinURLDir(site, feedSuffix), SourceFeedSuffix},
 		)
 	}
 	// 先尝试以当前链接为目录基路径进行拼接（适配子路径站点）
-	candidates = append(candidates,
+	paths := []string{
 		joinURLDir(site, "index.xml"),
 		joinURLDir(site, "atom.xml"),
 		joinURLDir(site, "rss.xml"),
 		joinURLDir(site, "feed"),
 		joinURLDir(site, "feed.xml"),
-	)
+	}
 	// 再尝试以站点根为基准的常见 endpoints
-	candidates = append(candidates,
+	paths = append(paths,
 		// 常见通用 endpoints
 		joinURL(site, "/feed"),
 		joinURL(site, "/feed/"),
@@ -68,86 +167,251 @@ func DiscoverFeed(ctx context.Context, cl *fetch.Client, site string, feedSuffix
 		// REST 风格
 		joinURL(site, "/api/rss"),
 	)
+	for _, u := range paths {
+		out = append(out, candidate{u, SourcePath})
+	}
+	return out
+}
 
-	// 全串行探测候选订阅，按顺序逐个尝试
-	for _, u := range candidates {
-		logx.Debugf("探测候选订阅：%s", u)
-		if ok := probeFeed(ctx, cl, u); ok {
-			return u, nil
-		}
+// discover 为发现流程的实现，探测轨迹记录在 tr 中。
+func discover(ctx context.Context, cl fetch.Fetcher, site string, feedSuffix string, o options, tr *Trace) (string, error) {
+	if u := probeCandidates(ctx, cl, candidates(site, feedSuffix), o.concurrency, tr); u != "" {
+		return u, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
 	// 回退：抓取 HTML 并解析 <link> 标签
+	tags, moved, err := findLinkTags(ctx, cl, site)
+	if err != nil {
+		tr.HTMLErr = err.Error()
+		return "", err
+	}
+	tr.LinkTags = tags
+	tr.SiteMoved = moved
+	if found := pickFeedLink(tags); found != "" {
+		a := probeFeed(ctx, cl, found)
+		a.Source = SourceLink
+		tr.add(a)
+		if a.OK {
+			logx.Debugf("从 <link> 发现订阅：%s", found)
+			return found, nil
+		}
+	}
+	// 有候选被 robots.txt 禁止时，无法确认站点没有订阅
+	for _, a := range tr.Attempts {
+		if a.Verdict == verdictRobots {
+			return "", fmt.Errorf("no feed discovered for %s: %w", site, fetch.ErrDisallowed)
+		}
+	}
+	return "", fmt.Errorf("no feed discovered for %s", site)
+}
+
+// probeCandidates 以有限并发探测候选，并保持优先级：
+// 只有当某个候选命中且所有更高优先级的候选都已失败时才确定胜者，
+// 随即取消其余仍在进行的请求。未命中返回空串。
+func probeCandidates(ctx context.Context, cl fetch.Fetcher, cands []candidate, workers int, tr *Trace) string {
+	if len(cands) == 0 {
+		return ""
+	}
+	pctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		i int
+		a Attempt
+	}
+	jobs := make(chan int)
+	results := make(chan result, len(cands))
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(1, workers), len(cands)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				logx.Debugf("探测候选订阅：%s", cands[i].url)
+				a := probeFeed(pctx, cl, cands[i].url)
+				a.Source = cands[i].source
+				results <- result{i: i, a: a}
+			}
+		}()
+	}
+	go func() {
+		// 按优先级依次派发；确定胜者后停止派发
+		defer close(jobs)
+		for i := range cands {
+			select {
+			case jobs <- i:
+			case <-pctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	done := make([]*Attempt, len(cands))
+	next, winner := 0, -1
+	for r := range results {
+		a := r.a
+		done[r.i] = &a
+		// 推进“已确定失败”的前缀，遇到命中即为胜者
+		for winner < 0 && next < len(cands) && done[next] != nil {
+			if done[next].OK {
+				winner = next
+				cancel()
+				break
+			}
+			next++
+		}
+	}
+	for _, a := range done {
+		if a != nil {
+			tr.add(*a)
+		}
+	}
+	if winner < 0 {
+		return ""
+	}
+	return cands[winner].url
+}
+
+// Winner 返回命中订阅的那次探测。
+func (t *Trace) Winner() (Attempt, bool) {
+	for _, a := range t.Attempts {
+		if a.OK && a.URL == t.Feed {
+			return a, true
+		}
+	}
+	return Attempt{}, false
+}
+
+func (t *Trace) add(a Attempt) {
+	if t != nil {
+		t.Attempts = append(t.Attempts, a)
+	}
+}
+
+// FindLinkTags 抓取站点首页并返回其中的订阅相关 <link> 声明：
+// rel 含 alternate 的全部链接，以及缺少 type 但后缀形似订阅的链接。
+func FindLinkTags(ctx context.Context, cl fetch.Fetcher, site string) ([]LinkTag, error) {
+	tags, _, err := findLinkTags(ctx, cl, site)
+	return tags, err
+}
+
+// findLinkTags 为 FindLinkTags 的实现，另返回首页永久重定向后的地址（未迁移时为空）。
+// 相对链接按重定向后的最终地址解析。
+func findLinkTags(ctx context.Context, cl fetch.Fetcher, site string) ([]LinkTag, string, error) {
 	resp, err := cl.Get(ctx, site)
 	if err != nil {
-		return "", fmt.Errorf("GET site %s: %w", site, err)
+		return nil, "", fmt.Errorf("GET site %s: %w", site, err)
 	}
 	defer resp.Body.Close()
-	b, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(b)))
+	moved, _ := fetch.MovedTo(resp)
+	if final := fetch.FinalURL(resp); final != "" {
+		site = final
+	}
+	b, _, err := fetch.ReadUTF8(resp, 2<<20)
 	if err != nil {
-		return "", fmt.Errorf("parse html: %w", err)
+		return nil, "", fmt.Errorf("read site %s: %w", site, err)
 	}
-	var found string
-	// 优先解析 rel=alternate 的订阅声明
-	doc.Find("link").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", fmt.Errorf("parse html: %w", err)
+	}
+	var out []LinkTag
+	doc.Find("link").Each(func(_ int, s *goquery.Selection) {
 		rel, _ := s.Attr("rel")
 		t, _ := s.Attr("type")
 		href, _ := s.Attr("href")
-		lt := strings.ToLower(t)
-		lr := strings.ToLower(rel)
-		if strings.Contains(lr, "alternate") && (strings.Contains(lt, "rss") || strings.Contains(lt, "atom") || strings.Contains(lt, "json")) {
-			found = joinURL(site, href)
-			return false
+		if strings.TrimSpace(href) == "" {
+			return
 		}
-		// 若缺少 type，也尝试根据后缀判断（谨慎）
-		if lt == "" {
-			lh := strings.ToLower(href)
-			if strings.HasSuffix(lh, ".xml") || strings.HasSuffix(lh, ".rss") || strings.HasSuffix(lh, ".atom") || strings.HasSuffix(lh, ".json") {
-				found = joinURL(site, href)
-				return false
-			}
+		if strings.Contains(strings.ToLower(rel), "alternate") || (t == "" && feedLikeHref(href)) {
+			out = append(out, LinkTag{Rel: rel, Type: t, Href: joinURL(site, href)})
 		}
-		return true
 	})
-	if found != "" && probeFeed(ctx, cl, found) {
-		logx.Debugf("从 <link> 发现订阅：%s", found)
-		return found, nil
+	return out, moved, nil
+}
+
+// pickFeedLink 选出第一个订阅声明：优先 rel=alternate 且 type 为 rss/atom/json，
+// 若缺少 type，也尝试根据后缀判断（谨慎）。
+func pickFeedLink(tags []LinkTag) string {
+	for _, t := range tags {
+		lt := strings.ToLower(t.Type)
+		lr := strings.ToLower(t.Rel)
+		if strings.Contains(lr, "alternate") && (strings.Contains(lt, "rss") || strings.Contains(lt, "atom") || strings.Contains(lt, "json")) {
+			return t.Href
+		}
+		if lt == "" && feedLikeHref(t.Href) {
+			return t.Href
+		}
 	}
-	return "", fmt.Errorf("no feed discovered for %s", site)
+	return ""
+}
+
+func feedLikeHref(href string) bool {
+	lh := strings.ToLower(href)
+	return strings.HasSuffix(lh, ".xml") || strings.HasSuffix(lh, ".rss") || strings.HasSuffix(lh, ".atom") || strings.HasSuffix(lh, ".json")
 }
 
-// probeFeed 粗略探测 URL 是否为订阅（根据 Content-Type/状态码）。
-func probeFeed(ctx context.Context, cl *fetch.Client, feedURL string) bool {
-	// 为单个候选设置较短超时，避免串行探测拖慢整体速度
+// probeFeed 粗略探测 URL 是否为订阅（根据 Content-Type/状态码），并返回探测明细。
+func probeFeed(ctx context.Context, cl fetch.Fetcher, feedURL string) Attempt {
+	a := Attempt{URL: feedURL}
+	start := time.Now()
+	// 为单个候选设置较短超时，避免个别候选拖慢整体速度
 	prCtx, cancel := context.WithTimeout(ctx, 6*time.Second)
 	defer cancel()
-	resp, err := cl.Get(prCtx, feedURL)
+	// 候选路径大多不存在，探测失败直接判定，不做重试
+	resp, err := cl.Get(prCtx, feedURL, fetch.NoRetry())
 	if err != nil {
-		return false
+		a.Verdict = verdictError
+		if ctx.Err() != nil {
+			a.Verdict = verdictCancelled
+		} else if errors.Is(err, fetch.ErrDisallowed) {
+			a.Verdict = verdictRobots
+		}
+		a.Err = err.Error()
+		var se *fetch.StatusError
+		if errors.As(err, &se) {
+			a.Status = se.Code
+		}
+		a.Duration = time.Since(start)
+		return a
 	}
 	defer resp.Body.Close()
+	a.Status = resp.StatusCode
 	// 读取少量内容用于嗅探，避免将 HTML 误判为订阅
 	head, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-	ct := strings.ToLower(resp.Header.Get("Content-Type"))
+	a.ContentType = resp.Header.Get("Content-Type")
+	a.Verdict, a.OK = sniff(strings.ToLower(a.ContentType), head)
+	a.Duration = time.Since(start)
+	return a
+}
+
+// sniff 根据 Content-Type 与内容开头判定是否为订阅。
+func sniff(ct string, head []byte) (string, bool) {
 	if strings.Contains(ct, "rss") || strings.Contains(ct, "atom") || strings.Contains(ct, "xml") {
-		return true
+		return verdictFeedType, true
 	}
 	if strings.Contains(ct, "json") || strings.Contains(ct, "feed+json") {
 		lb := bytes.ToLower(head)
 		if bytes.Contains(lb, []byte("jsonfeed")) || bytes.Contains(lb, []byte("\"version\":\"https://jsonfeed.org/version")) {
-			return true
+			return verdictJSONFeed, true
 		}
-		return false
+		return verdictJSONNotFeed, false
 	}
 	// 按内容粗略嗅探 XML/JSON Feed 标记
 	lb := strings.ToLower(string(head))
 	if strings.Contains(lb, "<rss") || strings.Contains(lb, "<feed") || strings.Contains(lb, "<rdf") {
-		return true
+		return verdictSniffXML, true
 	}
 	if strings.Contains(lb, "\"version\":\"https://jsonfeed.org/version") {
-		return true
+		return verdictSniffJSON, true
 	}
-	return false
+	return verdictNotFeed, false
 }
 
 // joinURL 将相对路径解析为绝对 URL。
@@ -191,22 +455,69 @@ func joinURLDir(base, ref string) string {
 	return u.ResolveReference(ru).String()
 }
 
+// ErrNotModified 表示订阅自上次抓取以来未变化（条件请求返回 304），调用方应保留已有文章。
+var ErrNotModified = fetch.ErrNotModified
+
 // ParseFeed 从订阅地址解析并返回归一化后的条目（最多返回 max 条，0 表示不限制）。
-func ParseFeed(ctx context.Context, cl *fetch.Client, feedURL string, max int) ([]Item, error) {
+// 客户端配置了校验值存储时发起条件请求；订阅未变化时返回 ErrNotModified。
+func ParseFeed(ctx context.Context, cl fetch.Fetcher, feedURL string, max int) ([]Item, error) {
+	res, err := ParseFeedDetail(ctx, cl, feedURL, max)
+	if err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
+// FeedResult 为 ParseFeedDetail 的结果。
+type FeedResult struct {
+	Items []Item
+	// Repairs 为解析前对订阅内容做的修复（见 Sanitize），为空表示订阅本身可直接解析
+	Repairs []string
+	// Moved 为订阅永久重定向（301/308）后的最终地址，未迁移时为空
+	Moved string
+}
+
+// ParseFeedDetail 与 ParseFeed 相同，但在直接解析失败时先用 Sanitize 修复常见缺陷再重试，
+// 并返回所做的修复。
+func ParseFeedDetail(ctx context.Context, cl fetch.Fetcher, feedURL string, max int) (FeedResult, error) {
+	var res FeedResult
 	reqCtx, cancel := context.WithTimeout(ctx, 25*time.Second)
 	defer cancel()
 	p := gofeed.NewParser()
 	// gofeed 不直接接收自定义 http.Client，因此先用自定义客户端抓取后再交给 gofeed 解析
-	resp, err := cl.Get(reqCtx, feedURL)
+	resp, err := cl.GetConditional(reqCtx, feedURL, fetch.Feed())
+	if errors.Is(err, fetch.ErrNotModified) {
+		return res, ErrNotModified
+	}
 	if err != nil {
-		return nil, fmt.Errorf("GET feed %s: %w", feedURL, err)
+		return res, fmt.Errorf("GET feed %s: %w", feedURL, err)
 	}
 	defer resp.Body.Close()
-	feed, err := p.Parse(resp.Body)
+	res.Moved, _ = fetch.MovedTo(resp)
+	// 先按 Content-Type/XML 声明转为 UTF-8，避免 GBK/Big5 订阅出现乱码
+	b, cs, err := fetch.ReadUTF8(resp, 0)
+	if err != nil {
+		return res, fmt.Errorf("read feed %s: %w", feedURL, err)
+	}
+	logx.Debugf("订阅编码：%s 编码=%s", feedURL, cs)
+	feed, err := p.Parse(bytes.NewReader(b))
 	if err != nil {
-		return nil, fmt.Errorf("parse feed %s: %w", feedURL, err)
+		fixed, repairs := Sanitize(b)
+		if len(repairs) == 0 {
+			return res, fmt.Errorf("parse feed %s: %w", feedURL, err)
+		}
+		var err2 error
+		if feed, err2 = p.Parse(bytes.NewReader(fixed)); err2 != nil {
+			return res, fmt.Errorf("parse feed %s: %w", feedURL, err)
+		}
+		res.Repairs = repairs
+	}
+	// 解析成功（含修复后解析成功）才记录校验值，避免解析失败的内容在下次被 304 跳过；
+	// 修复项由调用方保存，304 时沿用上次的记录
+	if err := cl.RememberValidator(ctx, feedURL, resp); err != nil {
+		logx.Warnf("记录订阅校验值失败：%s 错误=%v", feedURL, err)
 	}
-	items := make([]Item, 0, len(feed.Items))
+	res.Items = make([]Item, 0, len(feed.Items))
 	for _, it := range feed.Items {
 		item := Item{
 			Title:   safe(it.Title),
@@ -215,12 +526,12 @@ func ParseFeed(ctx context.Context, cl *fetch.Client, feedURL string, max int) (
 			Updated: pickTime(it.UpdatedParsed, it.PublishedParsed),
 			Created: pickTime(it.PublishedParsed, it.UpdatedParsed),
 		}
-		items = append(items, item)
-		if max > 0 && len(items) >= max {
+		res.Items = append(res.Items, item)
+		if max > 0 && len(res.Items) >= max {
 			break
 		}
 	}
-	return items, nil
+	return res, nil
 }
 
 // Item 为解析后的文章临时结构（供上层转换为 model.Post）。
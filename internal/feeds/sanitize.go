@@ -0,0 +1,141 @@
+package feeds
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+)
+
+// 订阅修复类型（记录在朋友的 repaired 字段中，便于告知站长）。
+const (
+	RepairBOM               = "bom"
+	RepairLeadingWhitespace = "leading_whitespace"
+	RepairLeadingGarbage    = "leading_garbage"
+	RepairControlChars      = "control_chars"
+	RepairBareAmpersand     = "bare_ampersand"
+	RepairHTMLEntities      = "html_entities"
+)
+
+// entityRef 匹配 & 开头的实体引用：数字引用或命名引用。
+var entityRef = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`)
+
+// xmlEntities 为 XML 预定义实体，无需修复。
+var xmlEntities = map[string]bool{"amp": true, "lt": true, "gt": true, "quot": true, "apos": true}
+
+var (
+	cdataStart = []byte("<![CDATA[")
+	cdataEnd   = []byte("]]>")
+)
+
+// Sanitize 修复常见的 XML 订阅缺陷，返回修复后的内容与所做修复（按发现顺序、去重）：
+// - 开头的 BOM（含多个或夹在空白中的 BOM）
+// - 根元素前的空白或其他内容（如 PHP 警告输出）
+// - XML 1.0 不允许的控制字符
+// - 未转义的 &（CDATA 内除外）
+// - HTML 命名实体（如 &nbsp;），改写为数字引用
+// JSON Feed 原样返回。
+func Sanitize(b []byte) ([]byte, []string) {
+	var repairs []string
+	note := func(r string) {
+		for _, x := range repairs {
+			if x == r {
+				return
+			}
+		}
+		repairs = append(repairs, r)
+	}
+
+	// 开头：BOM 与根元素前的内容
+	i := 0
+	for i < len(b) && b[i] != '<' {
+		if bytes.HasPrefix(b[i:], []byte("\xef\xbb\xbf")) {
+			note(RepairBOM)
+			i += 3
+			continue
+		}
+		if b[i] == '{' || b[i] == '[' {
+			// JSON Feed 不做 XML 修复
+			return b, nil
+		}
+		switch b[i] {
+		case ' ', '\t', '\r', '\n':
+			note(RepairLeadingWhitespace)
+		default:
+			note(RepairLeadingGarbage)
+		}
+		i++
+	}
+	if i >= len(b) {
+		return b, nil
+	}
+	b = b[i:]
+
+	out := make([]byte, 0, len(b)+64)
+	for i := 0; i < len(b); {
+		if bytes.HasPrefix(b[i:], cdataStart) {
+			end := bytes.Index(b[i+len(cdataStart):], cdataEnd)
+			if end < 0 {
+				end = len(b) - i - len(cdataStart)
+			} else {
+				end += len(cdataEnd)
+			}
+			seg := b[i : i+len(cdataStart)+end]
+			out = appendWithoutControls(out, seg, note)
+			i += len(seg)
+			continue
+		}
+		c := b[i]
+		switch {
+		case c == '&':
+			m := entityRef.FindSubmatch(b[i:])
+			switch {
+			case m == nil:
+				note(RepairBareAmpersand)
+				out = append(out, "&amp;"...)
+				i++
+			case m[1][0] == '#' || xmlEntities[string(m[1])]:
+				out = append(out, m[0]...)
+				i += len(m[0])
+			default:
+				if dec := html.UnescapeString(string(m[0])); dec != string(m[0]) {
+					note(RepairHTMLEntities)
+					for _, r := range dec {
+						out = append(out, fmt.Sprintf("&#%d;", r)...)
+					}
+				} else {
+					// 未知实体视为普通文本中的 &
+					note(RepairBareAmpersand)
+					out = append(out, "&amp;"...)
+					out = append(out, m[0][1:]...)
+				}
+				i += len(m[0])
+			}
+		case isIllegalControl(c):
+			note(RepairControlChars)
+			i++
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out, repairs
+}
+
+// appendWithoutControls 复制片段并去除非法控制字符（用于 CDATA）。
+func appendWithoutControls(out, seg []byte, note func(string)) []byte {
+	for _, c := range seg {
+		if isIllegalControl(c) {
+			note(RepairControlChars)
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// isIllegalControl 判断是否为 XML 1.0 不允许的 C0 控制字符（保留 \t \n \r）。
+// 多字节 UTF-8 序列的字节均 >= 0x80，不会被误删。
+func isIllegalControl(c byte) bool {
+	return c < 0x20 && c != '\t' && c != '\n' && c != '\r'
+}
@@ -0,0 +1,75 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// xmlDeclEncoding 匹配 XML 声明中的 encoding 属性（仅检查文档开头）。
+var xmlDeclEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// metaCharset 匹配 HTML 中 <meta charset> 或 http-equiv 的 content 里的 charset 声明。
+var metaCharset = regexp.MustCompile(`(?i)<meta[^>]*?charset\s*=\s*["']?\s*[A-Za-z0-9._:-]+`)
+
+// ReadUTF8 读取响应体（limit<=0 表示仅受客户端响应体上限约束）并转为 UTF-8，返回内容与检测到的编码名。
+func ReadUTF8(resp *http.Response, limit int64) ([]byte, string, error) {
+	var r io.Reader = resp.Body
+	if limit > 0 {
+		r = io.LimitReader(r, limit)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("read body: %w", err)
+	}
+	out, name := ToUTF8(b, resp.Header.Get("Content-Type"))
+	return out, name, nil
+}
+
+// ToUTF8 按 BOM、Content-Type 的 charset、XML 声明、HTML <meta charset> 的顺序检测编码，
+// 并转码为 UTF-8；无法判断时按 UTF-8 处理。XML 声明中的 encoding 会改写为 utf-8，
+// 避免解析器再次按原编码解码。
+func ToUTF8(b []byte, contentType string) ([]byte, string) {
+	name := DetectCharset(b, contentType)
+	if name != "utf-8" {
+		if enc, _ := charset.Lookup(name); enc != nil {
+			if out, err := enc.NewDecoder().Bytes(b); err == nil {
+				b = out
+			}
+		}
+	}
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	if m := xmlDeclEncoding.FindSubmatchIndex(b); m != nil && !strings.EqualFold(string(b[m[2]:m[3]]), "utf-8") {
+		b = append(append(append([]byte{}, b[:m[2]]...), "utf-8"...), b[m[3]:]...)
+	}
+	return b, name
+}
+
+// DetectCharset 返回内容的规范编码名（如 utf-8、gbk、big5）。
+func DetectCharset(b []byte, contentType string) string {
+	// BOM 与 Content-Type 为确定来源
+	if _, name, certain := charset.DetermineEncoding(b, contentType); certain {
+		return name
+	}
+	head := b
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if m := xmlDeclEncoding.FindSubmatch(head); m != nil {
+		if _, name := charset.Lookup(string(m[1])); name != "" {
+			return name
+		}
+	}
+	_, name, _ := charset.DetermineEncoding(b, "text/html")
+	// 无任何声明时 x/net 默认回退 windows-1252，这里改为 UTF-8；
+	// 页面明确声明 iso-8859-1/windows-1252（x/net 均报告为 windows-1252）时保留
+	if name == "windows-1252" && !metaCharset.Match(head) {
+		return "utf-8"
+	}
+	return name
+}
@@ -0,0 +1,88 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// hostDialer 负责拨号前的地址解析：
+// - hosts：类似 /etc/hosts 的覆盖表（主机名 -> IP）
+// - resolver：自定义 DNS 服务器，为空时使用系统解析
+// - block：直连请求拒绝内网地址（见 guardTransport），并直接拨号已校验的 IP，避免 DNS 重绑定
+type hostDialer struct {
+	d        *net.Dialer
+	hosts    map[string]string
+	resolver *net.Resolver
+	block    bool
+}
+
+// newHostDialer 校验覆盖表并创建拨号器；resolverAddr 形如 1.1.1.1:53（省略端口时默认 53）。
+func newHostDialer(opts Options) (*hostDialer, error) {
+	h := &hostDialer{d: &net.Dialer{Timeout: 10 * time.Second}, hosts: map[string]string{}, block: opts.BlockPrivate}
+	for name, ip := range opts.Hosts {
+		if _, err := netip.ParseAddr(ip); err != nil {
+			return nil, fmt.Errorf("hosts override %s: invalid ip %q", name, ip)
+		}
+		h.hosts[strings.ToLower(strings.TrimSuffix(name, "."))] = ip
+	}
+	if addr := strings.TrimSpace(opts.Resolver); addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "53")
+		}
+		h.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return h.d.DialContext(ctx, network, addr)
+			},
+		}
+	}
+	return h, nil
+}
+
+// lookup 解析主机：覆盖表优先，IP 字面量原样返回，其余走自定义或系统解析。
+func (h *hostDialer) lookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	if ip, ok := h.hosts[strings.ToLower(host)]; ok {
+		host = ip
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{ip}, nil
+	}
+	r := h.resolver
+	if r == nil {
+		r = net.DefaultResolver
+	}
+	return r.LookupNetIP(ctx, "ip", host)
+}
+
+func (h *hostDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	direct, _ := ctx.Value(directKey{}).(bool)
+	guard := h.block && direct
+	_, overridden := h.hosts[strings.ToLower(host)]
+	if !guard && !overridden && h.resolver == nil {
+		return h.d.DialContext(ctx, network, addr)
+	}
+	ips, err := h.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error = fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	for _, ip := range ips {
+		if guard && isPrivate(ip) {
+			continue
+		}
+		conn, err := h.d.DialContext(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
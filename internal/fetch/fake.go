@@ -0,0 +1,154 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// FakeResponse 为 Fake 对某个 URL 返回的固定响应。
+type FakeResponse struct {
+	Status int         // 0 视为 200
+	Header http.Header // 可包含 Content-Type、ETag 等
+	Body   string
+	Err    error // 非空时直接返回该错误（模拟网络失败）
+}
+
+// Fake 为内存中的 Fetcher：按 URL 返回登记的响应，未登记的 URL 返回 404。
+// 行为与 Client 保持一致：跟随带 Location 的 3xx（可用 Redirects 取得重定向链），
+// 非 2xx 返回 *StatusError，条件请求命中 ETag 时返回 ErrNotModified。
+// 可并发使用。
+type Fake struct {
+	mu         sync.Mutex
+	responses  map[string]FakeResponse
+	validators map[string]string // url -> ETag
+	requests   []string
+}
+
+var _ Fetcher = (*Fake)(nil)
+
+// NewFake 创建空的 Fake。
+func NewFake() *Fake {
+	return &Fake{responses: map[string]FakeResponse{}, validators: map[string]string{}}
+}
+
+// Set 登记 URL 的响应（覆盖已有登记）。
+func (f *Fake) Set(url string, r FakeResponse) *Fake {
+	f.mu.Lock()
+	f.responses[url] = r
+	f.mu.Unlock()
+	return f
+}
+
+// SetBody 以 200 和指定 Content-Type 登记 URL 的响应体。
+func (f *Fake) SetBody(url, contentType, body string) *Fake {
+	return f.Set(url, FakeResponse{Header: http.Header{"Content-Type": {contentType}}, Body: body})
+}
+
+// Requests 返回按顺序记录的全部请求 URL。
+func (f *Fake) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// Get 实现 Fetcher。
+func (f *Fake) Get(ctx context.Context, url string, _ ...CallOption) (*http.Response, error) {
+	return f.get(ctx, url, false)
+}
+
+// GetConditional 实现 Fetcher：登记的响应带 ETag 且与已记录的值相同时返回 ErrNotModified。
+func (f *Fake) GetConditional(ctx context.Context, url string, _ ...CallOption) (*http.Response, error) {
+	return f.get(ctx, url, true)
+}
+
+// RememberValidator 实现 Fetcher（仅记录 ETag）。
+func (f *Fake) RememberValidator(_ context.Context, url string, resp *http.Response) error {
+	if resp == nil {
+		return nil
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		f.mu.Lock()
+		f.validators[url] = etag
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+// fakeMaxRedirects 与 Client 默认的重定向上限一致。
+const fakeMaxRedirects = DefaultMaxRedirects
+
+func (f *Fake) get(ctx context.Context, rawURL string, conditional bool) (*http.Response, error) {
+	var prev *http.Response
+	for hop := 0; ; hop++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Response = prev
+		resp, err := f.respond(req, rawURL, conditional)
+		if err != nil {
+			return nil, err
+		}
+		loc := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || loc == "" {
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				return nil, &StatusError{Code: resp.StatusCode, Status: resp.Status}
+			}
+			return resp, nil
+		}
+		if hop >= fakeMaxRedirects {
+			return nil, fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, fakeMaxRedirects)
+		}
+		next, err := req.URL.Parse(loc)
+		if err != nil {
+			return nil, fmt.Errorf("fake: bad location %q: %w", loc, err)
+		}
+		prev, rawURL = resp, next.String()
+	}
+}
+
+// respond 返回单个 URL 的登记响应（不跟随重定向）。
+func (f *Fake) respond(req *http.Request, url string, conditional bool) (*http.Response, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, url)
+	r, ok := f.responses[url]
+	etag := f.validators[url]
+	f.mu.Unlock()
+	if !ok {
+		r = FakeResponse{Status: http.StatusNotFound}
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+	// 规范化键名，允许登记时写 "ETag" 等非规范形式
+	header := http.Header{}
+	for k, vs := range r.Header {
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+	if conditional && etag != "" && header.Get("ETag") == etag {
+		return nil, ErrNotModified
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}, nil
+}
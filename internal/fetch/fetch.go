@@ -1,90 +1,271 @@
-// 包 fetch 封装 HTTP 客户端（代理/超时/重试），用于抓取网页与订阅。
+// 包 fetch 封装 HTTP 客户端（代理/超时/重试/限速/条件请求/录制回放），用于抓取网页与订阅。
 package fetch
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net"
 	"net/http"
-	"net/url"
-	"os"
+	neturl "net/url"
 	"time"
+
+	"go-circle-of-friends/internal/model"
 )
 
 // Client 为带重试的 HTTP 客户端。
 type Client struct {
-	http  *http.Client
-	retry int
+	http       *http.Client
+	retry      int
+	validators ValidatorStore
+	maxPage    int64
+	maxFeed    int64
+	profile    *profileTransport
+	robots     *robotsCache // 为空表示不检查 robots.txt
+	certs      *certTracker
 }
 
 // Options 为客户端构造参数。
 type Options struct {
+	// ProxyHTTP/ProxyHTTPS 为按协议的默认代理（http/https/socks5，可带 user:pass@）
 	ProxyHTTP  string
 	ProxyHTTPS string
+	// ProxyRules 按主机路由到指定代理或直连，先于 ProxyHTTP/ProxyHTTPS 匹配
+	ProxyRules []ProxyRule
 	Timeout    time.Duration
 	Retry      int
+	// Validators 持久化条件请求的 ETag/Last-Modified，为空时 GetConditional 退化为 Get
+	Validators ValidatorStore
+	// RecordDir 非空时将每个响应（状态/头/正文）按 URL 录制到该目录
+	RecordDir string
+	// ReplayDir 非空时只从该目录回放录制的响应，完全不访问网络（与 RecordDir 互斥）
+	ReplayDir string
+	// MaxPerHost 为同一主机同时进行的请求数上限，<=0 不限制
+	MaxPerHost int
+	// HostDelay 为同一主机两次请求之间的最小间隔
+	HostDelay time.Duration
+	// RPS 为全局每秒请求数预算，<=0 不限制
+	RPS float64
+	// UserAgent 为全局 UA；为空时读取环境变量 COF_UA，仍为空则使用内置浏览器 UA
+	UserAgent string
+	// Headers 为附加到每个请求的全局请求头
+	Headers map[string]string
+	// HostProfiles 为按主机覆盖的 UA/请求头/Cookie
+	HostProfiles []HostProfile
+	// MaxPageBytes/MaxFeedBytes 为页面与订阅（见 Feed）响应体上限（解压后字节），0 使用默认值
+	MaxPageBytes int64
+	MaxFeedBytes int64
+	// MaxRedirects 为单次请求最多跟随的重定向次数，0 使用默认值
+	MaxRedirects int
+	// BlockPrivate 拒绝直连解析到私有/回环等内网地址的目标
+	BlockPrivate bool
+	// Robots 开启后按主机获取并缓存 robots.txt，禁止抓取的 URL 返回 ErrDisallowed
+	Robots bool
+	// Hosts 为类似 /etc/hosts 的解析覆盖（主机名 -> IP）
+	Hosts map[string]string
+	// Resolver 为自定义 DNS 服务器地址（如 1.1.1.1:53），为空使用系统解析
+	Resolver string
+}
+
+// ErrNotModified 表示条件请求命中，服务端返回 304。
+var ErrNotModified = errors.New("not modified")
+
+// ValidatorStore 按 URL 读写校验值（正常模式为 SQLite，极简模式为状态文件）。
+type ValidatorStore interface {
+	LoadValidator(ctx context.Context, url string) (model.Validator, bool, error)
+	SaveValidator(ctx context.Context, url string, v model.Validator) error
 }
 
-// New 创建客户端，支持 http/https 代理与基础超时配置。
+// New 创建客户端，支持 http/https/socks5 代理（按主机路由）与基础超时配置。
 func New(opts Options) (*Client, error) {
+	proxy, err := proxyFunc(opts)
+	if err != nil {
+		return nil, err
+	}
+	dialer, err := newHostDialer(opts)
+	if err != nil {
+		return nil, err
+	}
 	transport := &http.Transport{
-		Proxy: func(req *http.Request) (*url.URL, error) {
-			if req.URL.Scheme == "https" && opts.ProxyHTTPS != "" {
-				return url.Parse(opts.ProxyHTTPS)
-			}
-			if req.URL.Scheme == "http" && opts.ProxyHTTP != "" {
-				return url.Parse(opts.ProxyHTTP)
-			}
-			return http.ProxyFromEnvironment(req)
-		},
-		DialContext:           (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ResponseHeaderTimeout: 15 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 	}
-	cl := &http.Client{Transport: transport}
+	var rt http.RoundTripper = transport
+	// 按主机的 TLS 配置（自定义 CA / 跳过校验）
+	tt, err := newTLSTransport(transport, opts.HostProfiles)
+	if err != nil {
+		return nil, err
+	}
+	if tt != nil {
+		rt = tt
+	}
+	if opts.BlockPrivate {
+		rt = &guardTransport{base: rt, proxy: proxy}
+	}
+	// 限速只作用于真实网络请求，回放时不生效
+	if lim := newLimiter(opts.MaxPerHost, opts.HostDelay, opts.RPS); lim.enabled() {
+		rt = &limitTransport{base: rt, lim: lim}
+	}
+	profile := newProfileTransport(rt, opts)
+	rt = profile
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+	if opts.MaxPageBytes <= 0 {
+		opts.MaxPageBytes = DefaultMaxPageBytes
+	}
+	if opts.MaxFeedBytes <= 0 {
+		opts.MaxFeedBytes = DefaultMaxFeedBytes
+	}
+	switch {
+	case opts.RecordDir != "" && opts.ReplayDir != "":
+		return nil, errors.New("record and replay directories are mutually exclusive")
+	case opts.RecordDir != "":
+		rt = &recordTransport{base: rt, dir: opts.RecordDir, max: max(opts.MaxPageBytes, opts.MaxFeedBytes)}
+	case opts.ReplayDir != "":
+		rt = &replayTransport{dir: opts.ReplayDir}
+	}
+	cl := &http.Client{Transport: rt, CheckRedirect: checkRedirect(opts.MaxRedirects)}
 	if opts.Timeout <= 0 {
 		opts.Timeout = 20 * time.Second
 	}
 	cl.Timeout = opts.Timeout
-	return &Client{http: cl, retry: opts.Retry}, nil
+	c := &Client{
+		http:       cl,
+		retry:      opts.Retry,
+		validators: opts.Validators,
+		maxPage:    opts.MaxPageBytes,
+		maxFeed:    opts.MaxFeedBytes,
+		profile:    profile,
+		certs:      &certTracker{expires: map[string]time.Time{}},
+	}
+	if opts.Robots {
+		c.robots = &robotsCache{entries: map[string]*robotsEntry{}}
+		// 重定向的每一跳同样遵守目标主机的 robots.txt
+		limit := cl.CheckRedirect
+		cl.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if err := limit(req, via); err != nil {
+				return err
+			}
+			return c.checkRedirectRobots(req)
+		}
+	}
+	return c, nil
+}
+
+// Get 发起 GET 请求，可重试的失败（网络错误/429/5xx）按指数退避重试。
+func (c *Client) Get(ctx context.Context, url string, opts ...CallOption) (*http.Response, error) {
+	return c.get(ctx, url, model.Validator{}, c.callOptions(opts))
+}
+
+// GetConditional 与 Get 相同，但携带上次记录的 If-None-Match/If-Modified-Since；
+// 服务端返回 304 时返回 ErrNotModified（不重试）。
+// 校验值不会自动保存：调用方在成功处理响应后调用 RememberValidator，
+// 以免内容处理失败时下次被 304 跳过。读取校验值失败时返回错误，不静默退化为普通请求。
+func (c *Client) GetConditional(ctx context.Context, url string, opts ...CallOption) (*http.Response, error) {
+	var v model.Validator
+	if c.validators != nil {
+		var err error
+		if v, _, err = c.validators.LoadValidator(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+	return c.get(ctx, url, v, c.callOptions(opts))
 }
 
-// Get 请求带有指数退避（简单线性回退）重试。
-func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
-	var lastErr error
-	attempts := c.retry + 1
-	for i := 0; i < attempts; i++ {
-		req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		if reqErr != nil {
-			lastErr = fmt.Errorf("new request: %w", reqErr)
-			break
-		}
-		// 使用常见浏览器 UA，减少 403/反爬误判；支持环境变量覆盖（COF_UA）
-		ua := os.Getenv("COF_UA")
-		if ua == "" {
-			ua = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36"
-		}
-		req.Header.Set("User-Agent", ua)
+// RememberValidator 记录响应中的 ETag/Last-Modified，供下次条件请求使用。
+func (c *Client) RememberValidator(ctx context.Context, url string, resp *http.Response) error {
+	if c.validators == nil || resp == nil {
+		return nil
+	}
+	v := model.Validator{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	if v.ETag == "" && v.LastModified == "" {
+		return nil
+	}
+	return c.validators.SaveValidator(ctx, url, v)
+}
+
+func (c *Client) get(ctx context.Context, url string, cond model.Validator, co callOptions) (*http.Response, error) {
+	if co.allowPrivate {
+		if u, err := neturl.Parse(url); err == nil {
+			ctx = context.WithValue(ctx, privateHostKey{}, u.Hostname())
+		}
+	}
+	if c.robots != nil {
+		if err := c.checkRobots(ctx, url); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; ; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("new request: %w", err)
+		}
+		// UA/请求头/Cookie 由 profileTransport 按主机设置
+		if cond.ETag != "" {
+			req.Header.Set("If-None-Match", cond.ETag)
+		}
+		if cond.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cond.LastModified)
+		}
+		for k, vs := range co.header {
+			req.Header[k] = vs
+		}
 		resp, err := c.http.Do(req)
+		if err == nil {
+			c.certs.observe(resp.Request.URL.Hostname(), resp.TLS)
+		}
 		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			limit := c.maxPage
+			if co.feed {
+				limit = c.maxFeed
+			}
+			if err := limitBody(resp, limit); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
 			return resp, nil
 		}
+		if err == nil && resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			return nil, ErrNotModified
+		}
+		var wait time.Duration
 		if err == nil {
-			lastErr = fmt.Errorf("http status: %s", resp.Status)
+			err = &StatusError{Code: resp.StatusCode, Status: resp.Status}
+			wait = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 			if resp.Body != nil {
 				resp.Body.Close()
 			}
-		} else {
-			lastErr = err
 		}
+		if i >= co.retry || !retryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		// 服务端给出的 Retry-After 优先，过长时放弃而不是长时间阻塞
+		if wait > retryAfterMax {
+			return nil, err
+		}
+		if wait == 0 {
+			wait = backoff(i)
+		}
+		t := time.NewTimer(wait)
 		select {
 		case <-ctx.Done():
+			t.Stop()
 			return nil, ctx.Err()
-		case <-time.After(time.Duration(i+1) * 300 * time.Millisecond):
+		case <-t.C:
 		}
 	}
-	return nil, lastErr
 }
 
-// 备注：若某些站点仍返回 403，可按需设置环境变量 COF_UA 覆盖 UA。
+// StatusError 表示服务端返回了非 2xx 状态码。
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string { return "http status: " + e.Status }
+
+// 备注：若某些站点仍返回 403，可在 settings.yaml 的 HTTP.hosts 中为其单独配置 UA/请求头/Cookie。
@@ -0,0 +1,19 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+)
+
+// Fetcher 为抓取网页与订阅所需的接口，*Client 为默认实现（重试/限速/条件请求等）。
+// friends/feeds/aggregate 只依赖该接口，便于用 Fake 测试或包装缓存、追踪等装饰器。
+type Fetcher interface {
+	// Get 发起 GET 请求，非 2xx 返回 *StatusError。
+	Get(ctx context.Context, url string, opts ...CallOption) (*http.Response, error)
+	// GetConditional 携带上次记录的校验值发起请求，未变化时返回 ErrNotModified。
+	GetConditional(ctx context.Context, url string, opts ...CallOption) (*http.Response, error)
+	// RememberValidator 记录响应的 ETag/Last-Modified，供下次条件请求使用。
+	RememberValidator(ctx context.Context, url string, resp *http.Response) error
+}
+
+var _ Fetcher = (*Client)(nil)
@@ -0,0 +1,119 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// 默认上限：页面与订阅的响应体大小（按解压后字节计）、重定向次数。
+const (
+	DefaultMaxPageBytes = 4 << 20
+	DefaultMaxFeedBytes = 16 << 20
+	DefaultMaxRedirects = 10
+)
+
+var (
+	// ErrBodyTooLarge 表示响应体（解压后）超过上限，读取被中止。
+	ErrBodyTooLarge = errors.New("response body too large")
+	// ErrTooManyRedirects 表示重定向次数超过上限。
+	ErrTooManyRedirects = errors.New("too many redirects")
+	// ErrRedirectLoop 表示重定向回到了已访问过的地址。
+	ErrRedirectLoop = errors.New("redirect loop")
+	// ErrPrivateAddress 表示目标解析到私有/回环等内网地址而被拒绝。
+	ErrPrivateAddress = errors.New("refusing to fetch private or loopback address")
+)
+
+// checkRedirect 限制重定向次数并检测循环。
+func checkRedirect(max int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, max)
+		}
+		next := req.URL.String()
+		for _, v := range via {
+			if v.URL.String() == next {
+				return fmt.Errorf("%w: %s", ErrRedirectLoop, next)
+			}
+		}
+		return nil
+	}
+}
+
+// limitedBody 在读取超过 max 字节时返回 ErrBodyTooLarge，而不是静默截断。
+// 透明解压后再计数，因此同时防御压缩炸弹。
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+}
+
+func limitBody(resp *http.Response, max int64) error {
+	if max <= 0 {
+		return nil
+	}
+	if resp.ContentLength > max {
+		return fmt.Errorf("%w: content-length %d exceeds %d", ErrBodyTooLarge, resp.ContentLength, max)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, left: max}
+	return nil
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.left <= 0 {
+		// 多读 1 字节判断是否真的超限
+		var one [1]byte
+		if n, _ := b.ReadCloser.Read(one[:]); n > 0 {
+			return 0, ErrBodyTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.left {
+		p = p[:b.left]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.left -= int64(n)
+	return n, err
+}
+
+// isPrivate 判断地址是否属于回环、私有、链路本地、CGNAT、未指定或组播范围。
+func isPrivate(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || cgnat.Contains(ip)
+}
+
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// directKey 标记请求将直连目标（未经代理），拨号时据此校验目标地址。
+type directKey struct{}
+
+// privateHostKey 记录本次调用允许解析到内网地址的主机（见 AllowPrivate）。
+type privateHostKey struct{}
+
+// guardTransport 拒绝直连内网地址：IP 字面量在请求前检查，域名在拨号时解析后检查，
+// 并直接拨号已校验的 IP，避免 DNS 重绑定。经代理的请求由代理负责解析，不做限制。
+type guardTransport struct {
+	base  http.RoundTripper
+	proxy func(*http.Request) (*url.URL, error)
+}
+
+func (t *guardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if p, err := t.proxy(req); err != nil || p != nil {
+		return t.base.RoundTrip(req)
+	}
+	if h, _ := req.Context().Value(privateHostKey{}).(string); h != "" && strings.EqualFold(h, req.URL.Hostname()) {
+		return t.base.RoundTrip(req)
+	}
+	if ip, err := netip.ParseAddr(req.URL.Hostname()); err == nil && isPrivate(ip) {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPrivateAddress, req.URL.Host)
+	}
+	return t.base.RoundTrip(req.WithContext(context.WithValue(req.Context(), directKey{}, true)))
+}
@@ -0,0 +1,138 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// limiter 控制请求节奏：
+// - 每个主机同时进行的请求数上限（perHost，<=0 不限制）
+// - 同一主机两次请求之间的最小间隔（hostDelay）
+// - 全局每秒请求数预算（rps，<=0 不限制）
+type limiter struct {
+	perHost   int
+	hostDelay time.Duration
+	interval  time.Duration // 1/rps
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+	next  time.Time // 全局下一个可用时刻
+}
+
+type hostState struct {
+	sem  chan struct{}
+	next time.Time // 该主机下一个可用时刻
+}
+
+func newLimiter(perHost int, hostDelay time.Duration, rps float64) *limiter {
+	l := &limiter{perHost: perHost, hostDelay: hostDelay, hosts: map[string]*hostState{}}
+	if rps > 0 {
+		l.interval = time.Duration(float64(time.Second) / rps)
+	}
+	return l
+}
+
+// enabled 判断是否配置了任一限制，未配置时不包装 Transport。
+func (l *limiter) enabled() bool {
+	return l.perHost > 0 || l.hostDelay > 0 || l.interval > 0
+}
+
+func (l *limiter) host(key string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h := l.hosts[key]
+	if h == nil {
+		h = &hostState{}
+		if l.perHost > 0 {
+			h.sem = make(chan struct{}, l.perHost)
+		}
+		l.hosts[key] = h
+	}
+	return h
+}
+
+// reserve 预约主机间隔与全局预算中最早可用的时刻。
+func (l *limiter) reserve(h *hostState) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	at := now
+	if l.hostDelay > 0 {
+		if h.next.After(at) {
+			at = h.next
+		}
+		h.next = at.Add(l.hostDelay)
+	}
+	if l.interval > 0 {
+		if l.next.After(at) {
+			at = l.next
+		}
+		l.next = at.Add(l.interval)
+	}
+	return at
+}
+
+// acquire 阻塞直到允许对 key 主机发起请求；返回的 release 需在响应体关闭后调用。
+func (l *limiter) acquire(ctx context.Context, key string) (release func(), err error) {
+	h := l.host(key)
+	release = func() {}
+	if h.sem != nil {
+		select {
+		case h.sem <- struct{}{}:
+			release = func() { <-h.sem }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if d := time.Until(l.reserve(h)); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// limitTransport 在每次往返（包括重定向的每一跳与每次重试）前执行限速。
+type limitTransport struct {
+	base http.RoundTripper
+	lim  *limiter
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.lim.acquire(req.Context(), strings.ToLower(req.URL.Host))
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// 主机并发槽位保持到响应体读完关闭为止
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
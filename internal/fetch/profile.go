@@ -0,0 +1,90 @@
+package fetch
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// defaultUserAgent 为未配置时使用的常见浏览器 UA，减少 403/反爬误判。
+const defaultUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36"
+
+// HostProfile 为某个主机（含其子域名）的请求配置，覆盖全局 UA/请求头、附加 Cookie 并可调整证书校验。
+type HostProfile struct {
+	Host      string            // 如 example.com，同时匹配 www.example.com
+	UserAgent string            // 为空时沿用全局 UA
+	Headers   map[string]string // 覆盖同名全局请求头
+	Cookies   map[string]string
+	// CAFile 为额外信任的 CA 证书（PEM），用于自签名证书
+	CAFile string
+	// InsecureSkipVerify 跳过证书校验（需显式开启）
+	InsecureSkipVerify bool
+}
+
+// matches 判断主机名是否为 p.Host 或其子域名。
+func (p HostProfile) matches(host string) bool {
+	h := strings.ToLower(strings.TrimSuffix(p.Host, "."))
+	return host == h || strings.HasSuffix(host, "."+h)
+}
+
+// profileTransport 在每次往返（含重定向的每一跳）前按目标主机设置 UA、请求头与 Cookie。
+type profileTransport struct {
+	base      http.RoundTripper
+	defaultUA string
+	headers   map[string]string
+	hosts     []HostProfile // 按 Host 长度升序，越具体越后应用
+}
+
+func newProfileTransport(base http.RoundTripper, opts Options) *profileTransport {
+	ua := opts.UserAgent
+	if ua == "" {
+		// 兼容旧版：未在配置中设置时仍读取环境变量 COF_UA
+		ua = os.Getenv("COF_UA")
+	}
+	if ua == "" {
+		ua = defaultUserAgent
+	}
+	hosts := append([]HostProfile(nil), opts.HostProfiles...)
+	sort.SliceStable(hosts, func(i, j int) bool { return len(hosts[i].Host) < len(hosts[j].Host) })
+	return &profileTransport{base: base, defaultUA: ua, headers: opts.Headers, hosts: hosts}
+}
+
+// userAgentFor 返回访问该主机时使用的 UA。
+func (t *profileTransport) userAgentFor(host string) string {
+	ua := t.defaultUA
+	host = strings.ToLower(host)
+	for _, p := range t.hosts {
+		if p.matches(host) && p.UserAgent != "" {
+			ua = p.UserAgent
+		}
+	}
+	return ua
+}
+
+func (t *profileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	// 请求自身携带的请求头（条件请求、WithHeader）优先于配置
+	set := func(k, v string) {
+		if req.Header.Get(k) == "" {
+			r.Header.Set(k, v)
+		}
+	}
+	for k, v := range t.headers {
+		set(k, v)
+	}
+	host := strings.ToLower(r.URL.Hostname())
+	for _, p := range t.hosts {
+		if !p.matches(host) {
+			continue
+		}
+		for k, v := range p.Headers {
+			set(k, v)
+		}
+		for name, value := range p.Cookies {
+			r.AddCookie(&http.Cookie{Name: name, Value: value})
+		}
+	}
+	r.Header.Set("User-Agent", t.userAgentFor(host))
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,105 @@
+package fetch
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ProxyDirect 为代理规则中表示直连的取值。
+const ProxyDirect = "direct"
+
+// ProxyRule 将匹配的主机路由到指定代理或直连；Suffix 与 Regex 二选一。
+type ProxyRule struct {
+	Suffix string // 主机后缀，如 github.io（同时匹配 github.io 本身）
+	Regex  string // 对主机名的正则匹配
+	Proxy  string // 代理地址（http/https/socks5/socks5h，可带 user:pass@），或 "direct"
+}
+
+type proxyRoute struct {
+	suffix string
+	re     *regexp.Regexp
+	proxy  *url.URL // nil 表示直连
+}
+
+func (r proxyRoute) matches(host string) bool {
+	if r.re != nil {
+		return r.re.MatchString(host)
+	}
+	return host == r.suffix || strings.HasSuffix(host, "."+r.suffix)
+}
+
+// parseProxyURL 校验代理地址，支持 http/https/socks5/socks5h 及用户名密码认证。
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("proxy %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy %q: unsupported scheme %q", raw, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %q: missing host", raw)
+	}
+	return u, nil
+}
+
+// proxyFunc 生成 Transport.Proxy：按规则顺序取第一条匹配的路由，
+// 未匹配时按协议使用 ProxyHTTP/ProxyHTTPS，仍未配置则读取环境变量。
+func proxyFunc(opts Options) (func(*http.Request) (*url.URL, error), error) {
+	routes := make([]proxyRoute, 0, len(opts.ProxyRules))
+	for i, pr := range opts.ProxyRules {
+		var rt proxyRoute
+		switch {
+		case pr.Regex != "":
+			re, err := regexp.Compile(pr.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("proxy rule %d: %w", i, err)
+			}
+			rt.re = re
+		case pr.Suffix != "":
+			rt.suffix = strings.ToLower(strings.Trim(pr.Suffix, "."))
+		default:
+			return nil, fmt.Errorf("proxy rule %d: suffix or regex is required", i)
+		}
+		if !strings.EqualFold(strings.TrimSpace(pr.Proxy), ProxyDirect) {
+			u, err := parseProxyURL(pr.Proxy)
+			if err != nil {
+				return nil, fmt.Errorf("proxy rule %d: %w", i, err)
+			}
+			rt.proxy = u
+		}
+		routes = append(routes, rt)
+	}
+	var httpProxy, httpsProxy *url.URL
+	var err error
+	if opts.ProxyHTTP != "" {
+		if httpProxy, err = parseProxyURL(opts.ProxyHTTP); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ProxyHTTPS != "" {
+		if httpsProxy, err = parseProxyURL(opts.ProxyHTTPS); err != nil {
+			return nil, err
+		}
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		host := strings.ToLower(req.URL.Hostname())
+		for _, rt := range routes {
+			if rt.matches(host) {
+				return rt.proxy, nil
+			}
+		}
+		if req.URL.Scheme == "https" && httpsProxy != nil {
+			return httpsProxy, nil
+		}
+		if req.URL.Scheme == "http" && httpProxy != nil {
+			return httpProxy, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}, nil
+}
@@ -0,0 +1,139 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNotRecorded 表示回放模式下目录中没有该 URL 的录制响应（不会访问网络）。
+var ErrNotRecorded = errors.New("replay: no recorded response")
+
+// recording 为单个录制文件的内容：按 方法+URL 存储一次响应。
+// 文本响应体直接以字符串保存便于阅读与手工修改，二进制内容使用 base64。
+type recording struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 []byte      `json:"body_base64,omitempty"`
+}
+
+// recordingPath 返回 URL 对应的录制文件路径（sha256 命名，避免特殊字符）。
+func recordingPath(dir, method, rawURL string) string {
+	sum := sha256.Sum256([]byte(method + " " + rawURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// recordTransport 将经过的每个响应（含重定向中间跳转）写入目录。
+// 录制时按 max（页面与订阅上限中的较大者）限制读取，超限时与正常抓取一样返回 ErrBodyTooLarge。
+type recordTransport struct {
+	base http.RoundTripper
+	dir  string
+	max  int64
+	mu   sync.Mutex
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := limitBody(resp, t.max); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("record: read body %s: %w", req.URL, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	path := recordingPath(t.dir, req.Method, req.URL.String())
+	// 再次录制时条件请求得到的 304 不覆盖已有录制，否则回放时所有订阅都变成“未变化”
+	if resp.StatusCode == http.StatusNotModified {
+		if _, err := os.Stat(path); err == nil {
+			return resp, nil
+		}
+	}
+	rec := recording{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Header: resp.Header.Clone()}
+	if utf8.Valid(body) {
+		rec.Body = string(body)
+	} else {
+		rec.BodyBase64 = body
+	}
+	// 透明解压后 Content-Length/Content-Encoding 已不再对应保存的内容
+	rec.Header.Del("Content-Encoding")
+	rec.Header.Del("Content-Length")
+	if err := t.save(rec); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *recordTransport) save(rec recording) error {
+	b, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("record: encode %s: %w", rec.URL, err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return fmt.Errorf("record: mkdir %s: %w", t.dir, err)
+	}
+	if err := os.WriteFile(recordingPath(t.dir, rec.Method, rec.URL), b, 0o644); err != nil {
+		return fmt.Errorf("record: write %s: %w", rec.URL, err)
+	}
+	return nil
+}
+
+// replayTransport 只从目录读取录制响应，从不访问网络。
+type replayTransport struct {
+	dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	b, err := os.ReadFile(recordingPath(t.dir, req.Method, req.URL.String()))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("replay: read %s: %w", req.URL, err)
+	}
+	var rec recording
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, fmt.Errorf("replay: decode %s: %w", req.URL, err)
+	}
+	body := rec.BodyBase64
+	if body == nil {
+		body = []byte(rec.Body)
+	}
+	if rec.Header == nil {
+		rec.Header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, strings.TrimSpace(http.StatusText(rec.Status))),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
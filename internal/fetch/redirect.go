@@ -0,0 +1,49 @@
+package fetch
+
+import "net/http"
+
+// Hop 为重定向链中的一跳：URL 返回了 Status（3xx）并跳转到下一跳。
+type Hop struct {
+	URL    string
+	Status int
+}
+
+// Redirects 返回 resp 经过的重定向链（按发生顺序），未发生重定向时为空。
+// 依赖 http.Client 在跳转请求上保留的 Request.Response，Client 与 Fake 均满足。
+func Redirects(resp *http.Response) []Hop {
+	if resp == nil {
+		return nil
+	}
+	var hops []Hop
+	for req := resp.Request; req != nil && req.Response != nil && req.Response.Request != nil; req = req.Response.Request {
+		prev := req.Response
+		hops = append(hops, Hop{URL: prev.Request.URL.String(), Status: prev.StatusCode})
+	}
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	return hops
+}
+
+// FinalURL 返回 resp 的最终地址（跟随重定向之后）。
+func FinalURL(resp *http.Response) string {
+	if resp == nil || resp.Request == nil {
+		return ""
+	}
+	return resp.Request.URL.String()
+}
+
+// MovedTo 在 resp 经过了重定向且每一跳都是永久重定向（301/308）时返回最终地址，
+// 用于判断站点或订阅是否已迁移；存在临时重定向（302/303/307）时返回 false。
+func MovedTo(resp *http.Response) (string, bool) {
+	hops := Redirects(resp)
+	if len(hops) == 0 {
+		return "", false
+	}
+	for _, h := range hops {
+		if h.Status != http.StatusMovedPermanently && h.Status != http.StatusPermanentRedirect {
+			return "", false
+		}
+	}
+	return FinalURL(resp), true
+}
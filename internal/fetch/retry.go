@@ -0,0 +1,119 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 退避参数：第 n 次重试等待约 retryBase*2^n（带抖动），单次不超过 retryMaxDelay；
+// Retry-After 超过 retryAfterMax 时不再等待，直接返回错误。
+const (
+	retryBase     = 300 * time.Millisecond
+	retryMaxDelay = 10 * time.Second
+	retryAfterMax = time.Minute
+)
+
+// CallOption 为单次请求的参数覆盖。
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	retry        int  // <0 表示沿用客户端配置
+	feed         bool // 使用订阅的响应体上限
+	allowPrivate bool // 允许本次请求的主机解析到内网地址
+	header       http.Header
+}
+
+// Feed 标记本次请求抓取的是订阅，响应体上限使用 Options.MaxFeedBytes。
+func Feed() CallOption {
+	return func(o *callOptions) { o.feed = true }
+}
+
+// WithHeader 为本次请求附加请求头（如 API 的 Authorization），覆盖按主机配置的同名请求头。
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		o.header.Set(key, value)
+	}
+}
+
+// AllowPrivate 允许本次请求的目标主机解析到内网/回环地址（如配置的本地 API），
+// 不受 Options.BlockPrivate 限制；仅对请求 URL 的主机生效，重定向到其他主机时仍会校验。
+func AllowPrivate() CallOption {
+	return func(o *callOptions) { o.allowPrivate = true }
+}
+
+// NoRetry 使本次请求失败后不重试（如订阅候选探测）。
+func NoRetry() CallOption {
+	return WithRetry(0)
+}
+
+// WithRetry 覆盖本次请求的重试次数。
+func WithRetry(n int) CallOption {
+	return func(o *callOptions) {
+		if n < 0 {
+			n = 0
+		}
+		o.retry = n
+	}
+}
+
+func (c *Client) callOptions(opts []CallOption) callOptions {
+	o := callOptions{retry: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.retry < 0 {
+		o.retry = c.retry
+	}
+	return o
+}
+
+// retryable 判断失败是否值得重试：网络错误、408、429 与 5xx 可重试，其余 4xx 不重试；
+// 回放未命中、重定向超限/循环、内网地址等确定性失败同样不重试。
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusRequestTimeout || se.Code == http.StatusTooManyRequests || se.Code >= 500
+	}
+	for _, final := range []error{context.Canceled, ErrNotRecorded, ErrTooManyRedirects, ErrRedirectLoop, ErrPrivateAddress, ErrDisallowed} {
+		if errors.Is(err, final) {
+			return false
+		}
+	}
+	return true
+}
+
+// backoff 返回第 n 次（从 0 开始）重试前的等待时间：指数增长并在 [d/2, d) 内抖动。
+func backoff(n int) time.Duration {
+	d := retryBase << n
+	if d <= 0 || d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// parseRetryAfter 解析 Retry-After（秒数或 HTTP 日期），无法解析时返回 0。
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,235 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed 表示 robots.txt 禁止抓取该 URL（仅在开启 Robots 时返回）。
+var ErrDisallowed = errors.New("blocked by robots.txt")
+
+// robots.txt 缓存有效期：成功获取（含 4xx 视为全部允许）与获取失败分别计时。
+const (
+	robotsTTL      = 24 * time.Hour
+	robotsErrTTL   = 5 * time.Minute
+	robotsMaxBytes = 512 << 10
+)
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+type robotsGroup struct {
+	agents []string // 小写
+	rules  []robotsRule
+}
+
+// robotsFile 为解析后的 robots.txt；nil 表示全部允许。
+type robotsFile struct {
+	groups []robotsGroup
+}
+
+// parseRobots 解析 robots.txt：按 User-agent 分组，支持 Allow/Disallow 与 * / $ 通配。
+func parseRobots(body string) *robotsFile {
+	f := &robotsFile{}
+	var cur *robotsGroup
+	inRules := false
+	for _, line := range strings.Split(body, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+		switch key {
+		case "user-agent":
+			if cur == nil || inRules {
+				f.groups = append(f.groups, robotsGroup{})
+				cur = &f.groups[len(f.groups)-1]
+				inRules = false
+			}
+			cur.agents = append(cur.agents, strings.ToLower(val))
+		case "allow", "disallow":
+			if cur == nil {
+				continue
+			}
+			inRules = true
+			if val == "" {
+				// 空 Disallow 表示不限制
+				continue
+			}
+			cur.rules = append(cur.rules, robotsRule{allow: key == "allow", pattern: val, re: robotsPattern(val)})
+		}
+	}
+	return f
+}
+
+// robotsPattern 将路径规则转为正则：* 匹配任意字符，结尾 $ 锚定结尾，其余按前缀匹配。
+func robotsPattern(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(p), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// rules 返回适用于 UA 的规则：优先取 UA 中包含的最长 agent 名，否则使用 "*"。
+func (f *robotsFile) rules(ua string) []robotsRule {
+	ua = strings.ToLower(ua)
+	best, bestLen := []robotsRule(nil), 0
+	var star []robotsRule
+	for _, g := range f.groups {
+		for _, a := range g.agents {
+			switch {
+			case a == "*":
+				star = append(star, g.rules...)
+			case a != "" && strings.Contains(ua, a) && len(a) >= bestLen:
+				if len(a) > bestLen {
+					best = nil
+				}
+				best, bestLen = append(best, g.rules...), len(a)
+			}
+		}
+	}
+	if bestLen > 0 {
+		return best
+	}
+	return star
+}
+
+// allowed 按最长匹配规则判定，长度相同时 Allow 优先。
+func (f *robotsFile) allowed(ua, path string) bool {
+	if f == nil || path == "/robots.txt" {
+		return true
+	}
+	allow, matched := true, -1
+	for _, r := range f.rules(ua) {
+		if !r.re.MatchString(path) {
+			continue
+		}
+		if n := len(r.pattern); n > matched || (n == matched && r.allow) {
+			allow, matched = r.allow, n
+		}
+	}
+	return allow
+}
+
+type robotsEntry struct {
+	ready   chan struct{}
+	file    *robotsFile
+	expires time.Time
+}
+
+// done 报告该条目的 robots.txt 是否已获取完成。
+func (e *robotsEntry) done() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// robotsCache 按 scheme://host 缓存 robots.txt，同一主机并发请求只获取一次。
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+// checkRobots 判断 URL 是否允许以该主机的 UA 抓取；获取 robots.txt 失败时视为允许。
+func (c *Client) checkRobots(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	origin := u.Scheme + "://" + u.Host
+	rc := c.robots
+	rc.mu.Lock()
+	e := rc.entries[origin]
+	if e != nil && e.done() && !e.expires.IsZero() && time.Now().After(e.expires) {
+		e = nil
+	}
+	if e == nil {
+		e = &robotsEntry{ready: make(chan struct{})}
+		rc.entries[origin] = e
+		rc.mu.Unlock()
+		file, expires := c.fetchRobots(ctx, origin)
+		// 结果在锁内写入后才关闭 ready，其他请求只在 ready 关闭后读取
+		rc.mu.Lock()
+		e.file, e.expires = file, expires
+		close(e.ready)
+		rc.mu.Unlock()
+	} else {
+		rc.mu.Unlock()
+		select {
+		case <-e.ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if !e.file.allowed(c.profile.userAgentFor(u.Hostname()), path) {
+		return fmt.Errorf("%w: %s", ErrDisallowed, raw)
+	}
+	return nil
+}
+
+// robotsFetchKey 标记 robots.txt 自身的请求，其重定向不再检查 robots（避免等待自身的缓存条目）。
+type robotsFetchKey struct{}
+
+// checkRedirectRobots 在跟随重定向前检查下一跳是否被其主机的 robots.txt 禁止。
+func (c *Client) checkRedirectRobots(req *http.Request) error {
+	if skip, _ := req.Context().Value(robotsFetchKey{}).(bool); skip {
+		return nil
+	}
+	return c.checkRobots(req.Context(), req.URL.String())
+}
+
+// fetchRobots 获取并解析 robots.txt：2xx 按内容解析，4xx 视为全部允许，其余失败暂时允许并较快重试。
+func (c *Client) fetchRobots(ctx context.Context, origin string) (*robotsFile, time.Time) {
+	// 结果由同主机的所有请求共享，不随触发它的单个请求（如被取消的候选探测）一起取消
+	ctx, cancel := context.WithTimeout(context.WithValue(context.WithoutCancel(ctx), robotsFetchKey{}, true), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, time.Now().Add(robotsErrTTL)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, time.Now().Add(robotsErrTTL)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		b, err := io.ReadAll(io.LimitReader(resp.Body, robotsMaxBytes))
+		if err != nil {
+			return nil, time.Now().Add(robotsErrTTL)
+		}
+		return parseRobots(string(b)), time.Now().Add(robotsTTL)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return nil, time.Now().Add(robotsTTL)
+	default:
+		return nil, time.Now().Add(robotsErrTTL)
+	}
+}
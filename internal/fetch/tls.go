@@ -0,0 +1,98 @@
+package fetch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tlsTransport 按主机选择证书校验方式：匹配到 TLS 配置（自定义 CA 或显式跳过校验）的主机
+// 使用各自克隆的 Transport，其余主机沿用默认 Transport（系统根证书）。
+type tlsTransport struct {
+	base  http.RoundTripper
+	hosts []tlsHost // 按 Host 长度升序，越具体越后匹配
+}
+
+type tlsHost struct {
+	host string
+	rt   *http.Transport
+}
+
+// newTLSTransport 为配置了 TLS 的主机创建独立 Transport；没有任何 TLS 配置时返回 nil。
+func newTLSTransport(base *http.Transport, profiles []HostProfile) (*tlsTransport, error) {
+	t := &tlsTransport{base: base}
+	for _, hp := range profiles {
+		if hp.CAFile == "" && !hp.InsecureSkipVerify {
+			continue
+		}
+		cfg := &tls.Config{InsecureSkipVerify: hp.InsecureSkipVerify}
+		if hp.CAFile != "" {
+			pem, err := os.ReadFile(hp.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("tls %s: read ca file: %w", hp.Host, err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("tls %s: no certificates in %s", hp.Host, hp.CAFile)
+			}
+			cfg.RootCAs = pool
+		}
+		rt := base.Clone()
+		rt.TLSClientConfig = cfg
+		t.hosts = append(t.hosts, tlsHost{host: strings.ToLower(strings.TrimPrefix(hp.Host, ".")), rt: rt})
+	}
+	if len(t.hosts) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(t.hosts, func(i, j int) bool { return len(t.hosts[i].host) < len(t.hosts[j].host) })
+	return t, nil
+}
+
+func (t *tlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var rt http.RoundTripper = t.base
+	host := strings.ToLower(req.URL.Hostname())
+	for _, th := range t.hosts {
+		if host == th.host || strings.HasSuffix(host, "."+th.host) {
+			rt = th.rt
+		}
+	}
+	return rt.RoundTrip(req)
+}
+
+// certTracker 记录各主机最近一次握手时服务端证书的过期时间。
+type certTracker struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+func (t *certTracker) observe(host string, cs *tls.ConnectionState) {
+	if cs == nil || len(cs.PeerCertificates) == 0 {
+		return
+	}
+	t.mu.Lock()
+	t.expires[strings.ToLower(host)] = cs.PeerCertificates[0].NotAfter
+	t.mu.Unlock()
+}
+
+// CertObserver 为可选接口：返回最近一次访问该主机时服务端证书的过期时间。
+// *Client 实现该接口；Fake 等其他 Fetcher 可不实现。
+type CertObserver interface {
+	CertExpiry(host string) (time.Time, bool)
+}
+
+// CertExpiry 实现 CertObserver。
+func (c *Client) CertExpiry(host string) (time.Time, bool) {
+	c.certs.mu.Lock()
+	defer c.certs.mu.Unlock()
+	t, ok := c.certs.expires[strings.ToLower(host)]
+	return t, ok
+}
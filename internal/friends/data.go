@@ -0,0 +1,331 @@
+package friends
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"go-circle-of-friends/internal/config"
+	"go-circle-of-friends/internal/fetch"
+)
+
+// JSONFormats 为内置的友链数据格式（JSON 与 YAML 通用）：
+// - fcircle：hexo-circle-of-friends 的 {"friends": [["名称", "链接", "头像"], ...]}
+// - butterfly：Butterfly 主题 flink / Hexo _data/link.yml 的分组结构 [{"class_name": ..., "link_list": [{"name", "link", "avatar"}]}]
+// - list：Hugo 等数据文件常见的平铺列表 [{"name"/"title", "link"/"url", "avatar"/"image"}]
+var JSONFormats = map[string]config.JSONMapping{
+	"fcircle": {
+		Items:  "$.friends[*]",
+		Name:   "[0]",
+		Link:   "[1]",
+		Avatar: "[2]",
+	},
+	"butterfly": {
+		Items:  "$[*].link_list[*]||$.flink[*].link_list[*]||$.link_list[*]",
+		Name:   "name",
+		Link:   "link",
+		Avatar: "avatar",
+		Feed:   "feed||rss",
+	},
+	"list": {
+		Items:  "$[*]||$.friends[*]||$.links[*]",
+		Name:   "name||title",
+		Link:   "link||url||website||href",
+		Avatar: "avatar||image||icon||logo",
+		Feed:   "feed||rss",
+	},
+}
+
+// jsonFormatOrder 为未指定格式时自动识别的尝试顺序。
+var jsonFormatOrder = []string{"fcircle", "butterfly", "list"}
+
+// ParseFriendsData 读取 json/yaml 友链数据（src.Path 为本地文件，否则抓取 src.URL）并按映射抽取朋友信息。
+// src.Theme 为 JSONFormats 中的内置格式名，src.Mapping 中非空字段覆盖内置格式；
+// 二者均未指定条目路径时依次尝试内置格式，取第一个抽取到朋友的。
+func ParseFriendsData(ctx context.Context, cl fetch.Fetcher, src config.LinkSource) ([]config.StaticFriend, error) {
+	b, err := loadData(ctx, cl, src)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeData(b, src.Type)
+	if err != nil {
+		return nil, fmt.Errorf("decode friends %s %s: %w", src.Type, src.Location(), err)
+	}
+	format, m := src.Theme, src.Mapping
+	if format == "" && m.Items == "" {
+		for _, name := range jsonFormatOrder {
+			out, err := extractFriends(doc, src.URL, mergeMapping(JSONFormats[name], m))
+			if err != nil {
+				return nil, err
+			}
+			if len(out) > 0 {
+				return out, nil
+			}
+		}
+		return nil, nil
+	}
+	base, ok := JSONFormats[format]
+	if format != "" && !ok {
+		return nil, fmt.Errorf("unknown friends data format %q", format)
+	}
+	return extractFriends(doc, src.URL, mergeMapping(base, m))
+}
+
+// loadData 读取来源内容并转为 UTF-8：设置 Path 时读本地文件，否则抓取 URL。
+func loadData(ctx context.Context, cl fetch.Fetcher, src config.LinkSource) ([]byte, error) {
+	if src.Path != "" {
+		b, err := os.ReadFile(src.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read friends %s %s: %w", src.Type, src.Path, err)
+		}
+		b, _ = fetch.ToUTF8(b, "")
+		return b, nil
+	}
+	resp, err := cl.Get(ctx, src.URL)
+	if err != nil {
+		return nil, fmt.Errorf("GET friends %s %s: %w", src.Type, src.URL, err)
+	}
+	defer resp.Body.Close()
+	b, _, err := fetch.ReadUTF8(resp, 0)
+	if err != nil {
+		return nil, fmt.Errorf("read friends %s %s: %w", src.Type, src.URL, err)
+	}
+	return b, nil
+}
+
+// decodeData 将 json/yaml 内容解码为 JSON 风格的值（对象为 map[string]any，数字为 float64），
+// 以便两种格式共用同一套路径求值。
+func decodeData(b []byte, typ string) (any, error) {
+	var doc any
+	if typ == "yaml" {
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		return normalizeYAML(doc), nil
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// normalizeYAML 将 yaml.v3 解码出的值转为与 encoding/json 一致的类型。
+func normalizeYAML(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, val := range x {
+			x[k] = normalizeYAML(val)
+		}
+		return x
+	case map[any]any:
+		out := make(map[string]any, len(x))
+		for k, val := range x {
+			out[fmt.Sprint(k)] = normalizeYAML(val)
+		}
+		return out
+	case []any:
+		for i, val := range x {
+			x[i] = normalizeYAML(val)
+		}
+		return x
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	}
+	return v
+}
+
+// mergeMapping 用 override 中的非空字段覆盖 base。
+func mergeMapping(base, override config.JSONMapping) config.JSONMapping {
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&base.Items, override.Items},
+		{&base.Name, override.Name},
+		{&base.Link, override.Link},
+		{&base.Avatar, override.Avatar},
+		{&base.Feed, override.Feed},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	return base
+}
+
+// extractFriends 按映射从已解码的数据中抽取朋友，相对链接按来源地址绝对化（本地文件不处理）。
+func extractFriends(doc any, srcURL string, m config.JSONMapping) ([]config.StaticFriend, error) {
+	items := m.Items
+	if items == "" {
+		items = "$[*]"
+	}
+	var list []any
+	for _, p := range strings.Split(items, "||") {
+		got, err := jsonPath(doc, strings.TrimSpace(p))
+		if err != nil {
+			return nil, err
+		}
+		if len(got) > 0 {
+			list = got
+			break
+		}
+	}
+	var out []config.StaticFriend
+	for _, it := range list {
+		name, err := jsonField(it, m.Name)
+		if err != nil {
+			return nil, err
+		}
+		link, err := jsonField(it, m.Link)
+		if err != nil {
+			return nil, err
+		}
+		avatar, err := jsonField(it, m.Avatar)
+		if err != nil {
+			return nil, err
+		}
+		feed, err := jsonField(it, m.Feed)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" && link == "" {
+			continue
+		}
+		out = append(out, config.StaticFriend{
+			Name:   name,
+			Link:   abs(srcURL, link),
+			Avatar: abs(srcURL, avatar),
+			Feed:   abs(srcURL, feed),
+		})
+	}
+	return out, nil
+}
+
+// jsonField 在条目上求值字段表达式（支持 "||" 回退），返回第一个非空的字符串值。
+func jsonField(item any, expr string) (string, error) {
+	for _, p := range strings.Split(expr, "||") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		got, err := jsonPath(item, p)
+		if err != nil {
+			return "", err
+		}
+		for _, v := range got {
+			if s := jsonString(v); s != "" {
+				return s, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// jsonString 将字符串/数字转为字符串，其余类型返回空串。
+func jsonString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return ""
+}
+
+// jsonPath 在 v 上求值简化的 JSONPath，返回全部匹配值：
+// $ 为根（可省略），.key 或 ["key"] 取字段，[n] 取下标（负数从末尾计），[*] 展开数组元素或对象的值。
+// 路径不存在时返回空结果而非错误，仅语法错误报错。
+func jsonPath(v any, path string) ([]any, error) {
+	p := strings.TrimPrefix(path, "$")
+	cur := []any{v}
+	for p != "" {
+		var next []any
+		switch {
+		case p[0] == '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			key := p[:end]
+			p = p[end:]
+			if key == "" {
+				return nil, fmt.Errorf("json path %q: empty key", path)
+			}
+			next = jsonKey(cur, key)
+		case p[0] == '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path %q: missing ]", path)
+			}
+			sel := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			switch {
+			case sel == "*":
+				for _, c := range cur {
+					switch x := c.(type) {
+					case []any:
+						next = append(next, x...)
+					case map[string]any:
+						// 按键名排序，保证结果稳定
+						keys := make([]string, 0, len(x))
+						for k := range x {
+							keys = append(keys, k)
+						}
+						sort.Strings(keys)
+						for _, k := range keys {
+							next = append(next, x[k])
+						}
+					}
+				}
+			case len(sel) >= 2 && (sel[0] == '"' || sel[0] == '\'') && sel[len(sel)-1] == sel[0]:
+				next = jsonKey(cur, sel[1:len(sel)-1])
+			default:
+				n, err := strconv.Atoi(sel)
+				if err != nil {
+					return nil, fmt.Errorf("json path %q: bad index %q", path, sel)
+				}
+				for _, c := range cur {
+					if arr, ok := c.([]any); ok {
+						i := n
+						if i < 0 {
+							i += len(arr)
+						}
+						if i >= 0 && i < len(arr) {
+							next = append(next, arr[i])
+						}
+					}
+				}
+			}
+		default:
+			// 省略开头的点：name 等价于 .name
+			p = "." + p
+			continue
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// jsonKey 取每个对象的 key 字段。
+func jsonKey(cur []any, key string) []any {
+	var out []any
+	for _, c := range cur {
+		if obj, ok := c.(map[string]any); ok {
+			if val, ok := obj[key]; ok {
+				out = append(out, val)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package friends
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"go-circle-of-friends/internal/config"
+)
+
+// ErrNoFriendBlock 表示文本中没有可解析出朋友信息的 YAML/JSON 块。
+var ErrNoFriendBlock = errors.New("no friend yaml/json block found")
+
+// blockMapping 为申请块中的字段映射（与 list 格式一致，额外接受常见别名）。
+var blockMapping = config.JSONMapping{
+	Name:   "name||title",
+	Link:   "link||url||website||href",
+	Avatar: "avatar||image||icon||logo",
+	Feed:   "feed||rss",
+}
+
+// fencedBlock 匹配 Markdown 围栏代码块（``` 或 ~~~），捕获语言与内容。
+var fencedBlock = regexp.MustCompile("(?s)(```|~~~)[ \\t]*([A-Za-z]*)[^\\n]*\\n(.*?)\\n[ \\t]*(```|~~~)")
+
+// ParseFriendBlock 从友链申请（如 issue 正文）中解析朋友信息：
+// 依次尝试各个围栏代码块（json 按 JSON 解析，其余按 YAML 解析），取第一个解析出带链接朋友的；
+// 没有代码块时将整段正文按 YAML 解析。块可以是单个对象或对象列表。
+func ParseFriendBlock(body string) ([]config.StaticFriend, error) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	var blocks [][2]string
+	for _, m := range fencedBlock.FindAllStringSubmatch(body, -1) {
+		blocks = append(blocks, [2]string{strings.ToLower(m[2]), m[3]})
+	}
+	if len(blocks) == 0 {
+		blocks = append(blocks, [2]string{"yaml", body})
+	}
+	for _, b := range blocks {
+		typ := "yaml"
+		if b[0] == "json" {
+			typ = "json"
+		}
+		doc, err := decodeData([]byte(b[1]), typ)
+		if err != nil {
+			continue
+		}
+		m := blockMapping
+		m.Items = "$"
+		if _, ok := doc.([]any); ok {
+			m.Items = "$[*]"
+		}
+		list, err := extractFriends(doc, "", m)
+		if err != nil {
+			return nil, err
+		}
+		var out []config.StaticFriend
+		for _, f := range list {
+			if f.Link != "" {
+				out = append(out, f)
+			}
+		}
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+	return nil, ErrNoFriendBlock
+}
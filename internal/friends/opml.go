@@ -0,0 +1,70 @@
+package friends
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go-circle-of-friends/internal/config"
+	"go-circle-of-friends/internal/fetch"
+)
+
+// opmlOutline 为 OPML 的 outline 节点：带 xmlUrl 的为订阅，其余视为分类并递归其子节点。
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr"`
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	HTMLURL  string        `xml:"htmlUrl,attr"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlDoc struct {
+	XMLName xml.Name      `xml:"opml"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+// ParseFriendsOPML 读取 OPML 订阅列表（src.Path 为本地文件，否则抓取 src.URL），
+// 每个订阅成为一位朋友并带上其订阅地址（跳过发现）；缺少 htmlUrl 时以订阅所在站点根地址作为链接。
+func ParseFriendsOPML(ctx context.Context, cl fetch.Fetcher, src config.LinkSource) ([]config.StaticFriend, error) {
+	b, err := loadData(ctx, cl, src)
+	if err != nil {
+		return nil, err
+	}
+	var doc opmlDoc
+	if err := xml.NewDecoder(bytes.NewReader(b)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode friends opml %s: %w", src.Location(), err)
+	}
+	var out []config.StaticFriend
+	var walk func([]opmlOutline)
+	walk = func(list []opmlOutline) {
+		for _, o := range list {
+			if feed := strings.TrimSpace(o.XMLURL); feed != "" {
+				feed = abs(src.URL, feed)
+				link := abs(src.URL, o.HTMLURL)
+				if link == "" {
+					link = siteRoot(feed)
+				}
+				name := strings.TrimSpace(o.Text)
+				if name == "" {
+					name = strings.TrimSpace(o.Title)
+				}
+				out = append(out, config.StaticFriend{Name: name, Link: link, Feed: feed})
+			}
+			walk(o.Outlines)
+		}
+	}
+	walk(doc.Body)
+	return out, nil
+}
+
+// siteRoot 返回 URL 所在站点的根地址（scheme://host/），解析失败时返回空串。
+func siteRoot(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/"
+}
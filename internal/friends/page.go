@@ -4,9 +4,9 @@
 package friends
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"net/url"
 	"strings"
 
@@ -14,6 +14,7 @@ import (
 
 	"go-circle-of-friends/internal/config"
 	"go-circle-of-friends/internal/fetch"
+	"go-circle-of-friends/internal/logx"
 	"go-circle-of-friends/internal/rules"
 )
 
@@ -22,7 +23,7 @@ import (
 // - 文本：".name" 或 "."（取当前项文本）
 // - 属性："a@href"/"img@src"/"@href"（当前项属性）
 // - 回退：使用 "||" 连接多个候选，按先后尝试
-func ParseFriendsPage(ctx context.Context, cl *fetch.Client, pageURL string, preset rules.Preset) ([]config.StaticFriend, error) {
+func ParseFriendsPage(ctx context.Context, cl fetch.Fetcher, pageURL string, preset rules.Preset) ([]config.StaticFriend, error) {
 	if preset.FriendsPage == nil {
 		return nil, nil
 	}
@@ -31,8 +32,12 @@ func ParseFriendsPage(ctx context.Context, cl *fetch.Client, pageURL string, pre
 		return nil, fmt.Errorf("GET friends page %s: %w", pageURL, err)
 	}
 	defer resp.Body.Close()
-	b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(b)))
+	b, cs, err := fetch.ReadUTF8(resp, 0)
+	if err != nil {
+		return nil, fmt.Errorf("read friends page %s: %w", pageURL, err)
+	}
+	logx.Debugf("友链页编码：%s 编码=%s", pageURL, cs)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(b))
 	if err != nil {
 		return nil, fmt.Errorf("parse friends page html: %w", err)
 	}
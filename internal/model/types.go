@@ -1,4 +1,4 @@
-// 包 model 定义导出的数据模型（朋友/文章/统计/导出结构）。
+// 包 model 定义导出的数据模型（朋友/文章/统计/导出结构）及条件请求校验值。
 package model
 
 import "time"
@@ -9,19 +9,24 @@ type Friend struct {
 	Link      string    `json:"link"`
 	Avatar    string    `json:"avatar"`
 	Error     string    `json:"error,omitempty"`
+	Feed      string    `json:"feed,omitempty"`     // 发现的订阅地址
+	Repaired  string    `json:"repaired,omitempty"` // 订阅解析前做过的修复（逗号分隔），为空表示订阅合法
 	CreatedAt time.Time `json:"created_at"`
+
+	CertExpiresAt *time.Time `json:"cert_expires_at,omitempty"` // 站点 HTTPS 证书过期时间（最近一次抓取时记录）
 }
 
 // Post 为归一化后的文章条目。
 type Post struct {
-	Title     string    `json:"title"`
-	Created   time.Time `json:"created"`
-	Updated   time.Time `json:"updated"`
-	Link      string    `json:"link"`
-	Author    string    `json:"author"`
-	Avatar    string    `json:"avatar"`
-	Rule      string    `json:"rule"`
-	CreatedAt time.Time `json:"created_at"`
+	Title      string    `json:"title"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+	Link       string    `json:"link"`
+	Author     string    `json:"author"`
+	Avatar     string    `json:"avatar"`
+	Rule       string    `json:"rule"`
+	FriendLink string    `json:"friend_link,omitempty"` // 所属朋友链接，用于按朋友过滤
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // Stats 为聚合统计信息。
@@ -39,3 +44,9 @@ type Export struct {
 	Friends []Friend `json:"friends"`
 	Posts   []Post   `json:"posts"`
 }
+
+// Validator 为某个 URL 上次响应的缓存校验值（ETag/Last-Modified），用于条件请求。
+type Validator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
@@ -0,0 +1,90 @@
+// 包 schedule 提供常驻模式下的定时调度：
+// - 支持固定间隔（Every）与 cron 表达式（ParseCron）
+// - 每次触发可附加随机抖动，避免多实例同时请求
+// - 上一轮未结束时跳过本次触发（防重叠），ctx 取消后等待当前任务退出
+package schedule
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-circle-of-friends/internal/logx"
+)
+
+// Spec 计算下一次触发时间。
+type Spec interface {
+	Next(t time.Time) time.Time
+}
+
+type every time.Duration
+
+// Every 返回固定间隔的调度规则。
+func Every(d time.Duration) Spec { return every(d) }
+
+func (e every) Next(t time.Time) time.Time { return t.Add(time.Duration(e)) }
+
+// Options 为调度参数。
+type Options struct {
+	Spec       Spec
+	Jitter     time.Duration // 每次触发前附加 [0, Jitter) 的随机延迟
+	RunOnStart bool          // 启动后立即执行一次
+}
+
+// Run 按 Spec 循环触发 job，直到 ctx 取消；返回前等待正在执行的 job 结束。
+// job 收到的 ctx 与 Run 相同，因此取消会同时中断正在进行的一轮。
+func Run(ctx context.Context, opts Options, job func(context.Context) error) {
+	var (
+		running atomic.Bool
+		wg      sync.WaitGroup
+	)
+	trigger := func() {
+		if !running.CompareAndSwap(false, true) {
+			logx.Warnf("上一轮聚合仍在运行，跳过本次触发")
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer running.Store(false)
+			start := time.Now()
+			if err := job(ctx); err != nil {
+				logx.Errorf("定时任务失败：%v", err)
+				return
+			}
+			logx.Infof("定时任务完成：耗时=%s", time.Since(start).Round(time.Millisecond))
+		}()
+	}
+	if opts.RunOnStart {
+		trigger()
+	}
+	for {
+		next := opts.Spec.Next(time.Now())
+		if next.IsZero() {
+			logx.Errorf("调度规则没有后续触发时间，停止调度")
+			break
+		}
+		delay := time.Until(next) + jitter(opts.Jitter)
+		logx.Infof("下一次运行：%s", time.Now().Add(delay).Format("2006-01-02 15:04:05"))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			wg.Wait()
+			return
+		case <-timer.C:
+			trigger()
+		}
+	}
+	<-ctx.Done()
+	wg.Wait()
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
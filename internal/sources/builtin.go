@@ -0,0 +1,82 @@
+package sources
+
+import (
+	"context"
+	"errors"
+
+	"go-circle-of-friends/internal/config"
+	"go-circle-of-friends/internal/fetch"
+	"go-circle-of-friends/internal/friends"
+	"go-circle-of-friends/internal/rules"
+)
+
+func init() {
+	Register("page", newPage)
+	Register("json", newData)
+	Register("yaml", newData)
+	Register("opml", newOPML)
+}
+
+// pageSource 按 rules.yaml 中 theme 对应的预设解析友链页。
+type pageSource struct {
+	src config.LinkSource
+	d   Deps
+}
+
+func newPage(src config.LinkSource, d Deps) (FriendSource, error) {
+	if src.URL == "" {
+		return nil, errors.New("type page requires url")
+	}
+	if src.Path != "" {
+		return nil, errors.New("type page does not support path")
+	}
+	return &pageSource{src: src, d: d}, nil
+}
+
+func (s *pageSource) Friends(ctx context.Context) ([]config.StaticFriend, error) {
+	var preset rules.Preset
+	if s.d.Rules != nil {
+		if p, ok := s.d.Rules.GetPreset(s.src.Theme); ok {
+			preset = p
+		}
+	}
+	return friends.ParseFriendsPage(ctx, s.d.Fetch, s.src.URL, preset)
+}
+
+// fileSource 为读取本地 path 或远程 url 的数据文件来源（json/yaml/opml）。
+type fileSource struct {
+	src   config.LinkSource
+	cl    fetch.Fetcher
+	parse func(context.Context, fetch.Fetcher, config.LinkSource) ([]config.StaticFriend, error)
+}
+
+func (s *fileSource) Friends(ctx context.Context) ([]config.StaticFriend, error) {
+	return s.parse(ctx, s.cl, s.src)
+}
+
+// checkLocation 要求 url 与 path 恰好设置其一。
+func checkLocation(src config.LinkSource) error {
+	if (src.URL == "") == (src.Path == "") {
+		return errors.New("exactly one of url and path is required")
+	}
+	return nil
+}
+
+// newData 创建 json/yaml 友链数据来源（内置格式见 friends.JSONFormats）。
+func newData(src config.LinkSource, d Deps) (FriendSource, error) {
+	if err := checkLocation(src); err != nil {
+		return nil, err
+	}
+	if _, ok := friends.JSONFormats[src.Theme]; src.Theme != "" && !ok {
+		return nil, errors.New("unknown friends data format " + src.Theme)
+	}
+	return &fileSource{src: src, cl: d.Fetch, parse: friends.ParseFriendsData}, nil
+}
+
+// newOPML 创建 OPML 订阅列表来源。
+func newOPML(src config.LinkSource, d Deps) (FriendSource, error) {
+	if err := checkLocation(src); err != nil {
+		return nil, err
+	}
+	return &fileSource{src: src, cl: d.Fetch, parse: friends.ParseFriendsOPML}, nil
+}
@@ -0,0 +1,163 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go-circle-of-friends/internal/config"
+	"go-circle-of-friends/internal/fetch"
+	"go-circle-of-friends/internal/friends"
+	"go-circle-of-friends/internal/logx"
+)
+
+func init() {
+	Register("issues", newIssues)
+}
+
+// defaultIssuesAPI 为未设置 url 时使用的 GitHub API 地址；Gitea 使用 https://gitea.example/api/v1。
+const defaultIssuesAPI = "https://api.github.com"
+
+// issuesOptions 为 issues 来源的 options 配置块。
+type issuesOptions struct {
+	Repo     string `yaml:"repo"`      // owner/name
+	Label    string `yaml:"label"`     // 多个标签用逗号分隔（需同时具备），为空不过滤
+	State    string `yaml:"state"`     // open（默认）|closed|all
+	Token    string `yaml:"token"`     // 访问令牌（私有仓库或提高限额）
+	TokenEnv string `yaml:"token_env"` // 从该环境变量读取令牌，避免写入配置文件
+	PerPage  int    `yaml:"per_page"`  // 每页条数，默认 100
+	MaxPages int    `yaml:"max_pages"` // 最多翻页数，默认 10
+	// AllowPrivate 允许 API 地址解析到内网/回环地址（本地 Gitea 或模拟服务），只对该 API 主机生效
+	AllowPrivate bool `yaml:"allow_private"`
+}
+
+// issuesSource 分页读取 GitHub/Gitea 风格的 issues 接口，从每个 issue 正文中的 YAML/JSON 块解析朋友。
+type issuesSource struct {
+	base string
+	opts issuesOptions
+	cl   fetch.Fetcher
+}
+
+func newIssues(src config.LinkSource, d Deps) (FriendSource, error) {
+	o := issuesOptions{State: "open", PerPage: 100, MaxPages: 10}
+	if err := src.DecodeOptions(&o); err != nil {
+		return nil, err
+	}
+	if owner, name, ok := strings.Cut(o.Repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("issues: options.repo must be owner/name, got %q", o.Repo)
+	}
+	switch o.State {
+	case "open", "closed", "all":
+	default:
+		return nil, fmt.Errorf("issues: options.state must be open, closed or all, got %q", o.State)
+	}
+	if o.PerPage <= 0 || o.MaxPages <= 0 {
+		return nil, errors.New("issues: options.per_page and options.max_pages must be > 0")
+	}
+	if o.TokenEnv != "" && o.Token == "" {
+		o.Token = os.Getenv(o.TokenEnv)
+	}
+	base := strings.TrimRight(src.URL, "/")
+	if base == "" {
+		base = defaultIssuesAPI
+	}
+	if _, err := url.Parse(base); err != nil {
+		return nil, fmt.Errorf("issues: bad url %q: %w", src.URL, err)
+	}
+	return &issuesSource{base: base, opts: o, cl: d.Fetch}, nil
+}
+
+// issue 为接口返回的 issue 中用到的字段（GitHub 与 Gitea 相同）。
+type issue struct {
+	Number      int             `json:"number"`
+	Title       string          `json:"title"`
+	Body        string          `json:"body"`
+	PullRequest json.RawMessage `json:"pull_request"`
+}
+
+func (s *issuesSource) Friends(ctx context.Context) ([]config.StaticFriend, error) {
+	var out []config.StaticFriend
+	for page := 1; page <= s.opts.MaxPages; page++ {
+		list, more, err := s.page(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, is := range list {
+			// GitHub 的 issues 接口同时返回 PR
+			if len(is.PullRequest) > 0 && string(is.PullRequest) != "null" {
+				continue
+			}
+			found, err := friends.ParseFriendBlock(is.Body)
+			if err != nil {
+				logx.Warnf("issue #%d（%s）无法解析友链信息：%v", is.Number, is.Title, err)
+				continue
+			}
+			for _, f := range found {
+				if f.Name == "" {
+					f.Name = strings.TrimSpace(is.Title)
+				}
+				out = append(out, f)
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	return out, nil
+}
+
+// page 读取一页 issues，并报告是否还有下一页；同时携带 per_page（GitHub）与 limit（Gitea）。
+// 服务端可能把每页条数压到更小（Gitea 的 MAX_RESPONSE_ITEMS 默认 50），因此不按条数判断末页：
+// 有 Link 头时以其中是否含 rel="next" 为准，否则读到空页为止。
+func (s *issuesSource) page(ctx context.Context, page int) ([]issue, bool, error) {
+	q := url.Values{}
+	q.Set("state", s.opts.State)
+	q.Set("type", "issues")
+	q.Set("per_page", strconv.Itoa(s.opts.PerPage))
+	q.Set("limit", strconv.Itoa(s.opts.PerPage))
+	q.Set("page", strconv.Itoa(page))
+	if s.opts.Label != "" {
+		q.Set("labels", s.opts.Label)
+	}
+	u := s.base + "/repos/" + s.opts.Repo + "/issues?" + q.Encode()
+	callOpts := []fetch.CallOption{fetch.WithHeader("Accept", "application/json")}
+	if s.opts.AllowPrivate {
+		callOpts = append(callOpts, fetch.AllowPrivate())
+	}
+	if s.opts.Token != "" {
+		callOpts = append(callOpts, fetch.WithHeader("Authorization", "token "+s.opts.Token))
+	}
+	resp, err := s.cl.Get(ctx, u, callOpts...)
+	if err != nil {
+		return nil, false, fmt.Errorf("GET issues %s page %d: %w", s.opts.Repo, page, err)
+	}
+	defer resp.Body.Close()
+	var list []issue
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, false, fmt.Errorf("decode issues %s page %d: %w", s.opts.Repo, page, err)
+	}
+	more := len(list) > 0
+	if link := resp.Header.Get("Link"); link != "" {
+		more = more && hasNextLink(link)
+	}
+	return list, more, nil
+}
+
+// hasNextLink 判断 Link 头中是否含 rel="next" 的链接。
+func hasNextLink(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		for _, param := range strings.Split(part, ";")[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(k, "rel") && slices.Contains(strings.Fields(strings.Trim(v, `"`)), "next") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+// 包 sources 提供友链来源的注册表：每种 LINK.type 对应一个 Factory，
+// 由 LINK 条目创建 FriendSource 并返回朋友列表。内置 page/json/yaml/opml，
+// 新来源在 init 中调用 Register 注册，自身配置放在 LINK 条目的 options 块中（见 config.LinkSource.DecodeOptions）。
+package sources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go-circle-of-friends/internal/config"
+	"go-circle-of-friends/internal/fetch"
+	"go-circle-of-friends/internal/rules"
+)
+
+// FriendSource 为一个已配置的友链来源。
+type FriendSource interface {
+	Friends(ctx context.Context) ([]config.StaticFriend, error)
+}
+
+// Deps 为来源共享的依赖。
+type Deps struct {
+	Fetch fetch.Fetcher
+	Rules *rules.Rules // 可为 nil
+}
+
+// Factory 由 LINK 条目创建来源；配置错误应在此返回，以便启动时即可发现。
+type Factory func(src config.LinkSource, d Deps) (FriendSource, error)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Factory{}
+)
+
+// Register 注册来源类型；重复注册同一类型会 panic。
+func Register(typ string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[typ]; ok {
+		panic("sources: duplicate type " + typ)
+	}
+	registry[typ] = f
+}
+
+// Types 返回已注册的来源类型（排序后）。
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]string, 0, len(registry))
+	for t := range registry {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// New 按 src.Type 创建来源（空类型视为 page）。
+func New(src config.LinkSource, d Deps) (FriendSource, error) {
+	typ := src.Type
+	if typ == "" {
+		typ = "page"
+	}
+	mu.RLock()
+	f, ok := registry[typ]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported LINK type %q (available: %v)", typ, Types())
+	}
+	return f(src, d)
+}
+
+// Validate 依次创建全部来源以检查配置，返回第一个错误。
+func Validate(srcs []config.LinkSource) error {
+	for i, src := range srcs {
+		if _, err := New(src, Deps{}); err != nil {
+			return fmt.Errorf("LINK[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Result 为单个来源的解析结果。
+type Result struct {
+	Source  config.LinkSource
+	Friends []config.StaticFriend
+	Err     error
+}
+
+// Collect 依次解析全部来源；单个来源失败不影响其他来源，错误记录在对应的 Result 中。
+func Collect(ctx context.Context, srcs []config.LinkSource, d Deps) []Result {
+	out := make([]Result, 0, len(srcs))
+	for _, src := range srcs {
+		r := Result{Source: src}
+		s, err := New(src, d)
+		if err == nil {
+			r.Friends, err = s.Friends(ctx)
+		}
+		r.Err = err
+		out = append(out, r)
+	}
+	return out
+}
@@ -36,7 +36,12 @@ func OpenSQLite(path string) (*SQLite, error) {
 func (s *SQLite) Close() error { return s.db.Close() }
 
 // Reset 清空业务数据表（不删除数据库文件）。
+// 条件请求校验值随文章一并清除，否则 304 会让已清空的文章无法恢复；
+// 订阅发现缓存（feed_cache）不属于业务数据，予以保留，避免重置后全量重新发现。
 func (s *SQLite) Reset(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM http_validators`); err != nil {
+		return fmt.Errorf("delete http validators: %w", err)
+	}
 	// 顺序：先清 posts 再清 friends，避免潜在外键依赖（当前无外键，仅为稳妥）
 	if _, err := s.db.ExecContext(ctx, `DELETE FROM posts`); err != nil {
 		return fmt.Errorf("delete posts: %w", err)
@@ -47,6 +52,14 @@ func (s *SQLite) Reset(ctx context.Context) error {
 	return nil
 }
 
+// Vacuum 整理数据库文件，回收已删除数据占用的空间。
+func (s *SQLite) Vacuum(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	return nil
+}
+
 // migrate 执行建表语句，保持幂等。
 func (s *SQLite) migrate() error {
 	stmts := []string{
@@ -66,6 +79,23 @@ func (s *SQLite) migrate() error {
             avatar TEXT,
             rule TEXT,
             created_at TIMESTAMP
+        );`,
+		`CREATE TABLE IF NOT EXISTS feed_cache (
+            link TEXT PRIMARY KEY,
+            feed TEXT,
+            strategy TEXT,
+            discovered_at TIMESTAMP
+        );`,
+		`CREATE TABLE IF NOT EXISTS friend_aliases (
+            alias TEXT PRIMARY KEY,
+            link TEXT NOT NULL,
+            migrated_at TIMESTAMP
+        );`,
+		`CREATE TABLE IF NOT EXISTS http_validators (
+            url TEXT PRIMARY KEY,
+            etag TEXT,
+            last_modified TEXT,
+            updated_at TIMESTAMP
         );`,
 	}
 	for _, q := range stmts {
@@ -73,15 +103,66 @@ func (s *SQLite) migrate() error {
 			return fmt.Errorf("exec migrate: %w", err)
 		}
 	}
+	// 增量列：旧库通过 ALTER TABLE 补齐，新库同样走此路径
+	cols := []struct{ table, name, typ string }{
+		{"posts", "friend_link", "TEXT"},
+		{"friends", "feed", "TEXT"},
+		{"friends", "repaired", "TEXT"},
+		{"friends", "cert_expires_at", "TIMESTAMP"},
+	}
+	for _, c := range cols {
+		if err := s.addColumnIfMissing(c.table, c.name, c.typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing 在列不存在时追加列，保证旧数据库可平滑升级。
+func (s *SQLite) addColumnIfMissing(table, column, typ string) error {
+	rows, err := s.db.Query(`PRAGMA table_info(` + table + `)`)
+	if err != nil {
+		return fmt.Errorf("table info %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			ctype   string
+			notnull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			return fmt.Errorf("scan table info %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate table info %s: %w", table, err)
+	}
+	rows.Close()
+	if _, err := s.db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + typ); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
+	}
 	return nil
 }
 
 // UpsertFriend 插入或更新朋友信息（link 唯一约束）。
 func (s *SQLite) UpsertFriend(ctx context.Context, f model.Friend) error {
-	_, err := s.db.ExecContext(ctx, `INSERT INTO friends(name, link, avatar, error, created_at)
-        VALUES(?,?,?,?,?)
-        ON CONFLICT(link) DO UPDATE SET name=excluded.name, avatar=excluded.avatar, error=excluded.error`,
-		f.Name, f.Link, f.Avatar, f.Error, nowOr(f.CreatedAt))
+	// 本次未观察到证书（如抓取失败或 HTTP 站点）时保留上次记录的过期时间
+	var certExpires any
+	if f.CertExpiresAt != nil {
+		certExpires = *f.CertExpiresAt
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO friends(name, link, avatar, error, feed, repaired, cert_expires_at, created_at)
+        VALUES(?,?,?,?,?,?,?,?)
+        ON CONFLICT(link) DO UPDATE SET name=excluded.name, avatar=excluded.avatar, error=excluded.error, feed=excluded.feed, repaired=excluded.repaired,
+            cert_expires_at=COALESCE(excluded.cert_expires_at, friends.cert_expires_at)`,
+		f.Name, f.Link, f.Avatar, f.Error, f.Feed, f.Repaired, certExpires, nowOr(f.CreatedAt))
 	if err != nil {
 		return fmt.Errorf("upsert friend %s: %w", f.Link, err)
 	}
@@ -93,10 +174,10 @@ func (s *SQLite) UpsertPost(ctx context.Context, p model.Post) error {
 	if p.Link == "" {
 		return errors.New("post.link required")
 	}
-	_, err := s.db.ExecContext(ctx, `INSERT INTO posts(title, created, updated, link, author, avatar, rule, created_at)
-        VALUES(?,?,?,?,?,?,?,?)
-        ON CONFLICT(link) DO UPDATE SET title=excluded.title, created=excluded.created, updated=excluded.updated, author=excluded.author, avatar=excluded.avatar, rule=excluded.rule`,
-		p.Title, p.Created, p.Updated, p.Link, p.Author, p.Avatar, p.Rule, nowOr(p.CreatedAt))
+	_, err := s.db.ExecContext(ctx, `INSERT INTO posts(title, created, updated, link, author, avatar, rule, friend_link, created_at)
+        VALUES(?,?,?,?,?,?,?,?,?)
+        ON CONFLICT(link) DO UPDATE SET title=excluded.title, created=excluded.created, updated=excluded.updated, author=excluded.author, avatar=excluded.avatar, rule=excluded.rule, friend_link=excluded.friend_link`,
+		p.Title, p.Created, p.Updated, p.Link, p.Author, p.Avatar, p.Rule, p.FriendLink, nowOr(p.CreatedAt))
 	if err != nil {
 		return fmt.Errorf("upsert post %s: %w", p.Link, err)
 	}
@@ -105,18 +186,29 @@ func (s *SQLite) UpsertPost(ctx context.Context, p model.Post) error {
 
 // ListFriends 返回全部朋友，若 created_at 为空则在代码层兜底为当前时间。
 func (s *SQLite) ListFriends(ctx context.Context) ([]model.Friend, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT name, link, avatar, COALESCE(error,''), created_at FROM friends ORDER BY name`)
+	rows, err := s.db.QueryContext(ctx, `SELECT `+friendColumns+` FROM friends ORDER BY name`)
 	if err != nil {
 		return nil, fmt.Errorf("query friends: %w", err)
 	}
 	defer rows.Close()
+	return scanFriends(rows)
+}
+
+const friendColumns = `name, link, avatar, COALESCE(error,''), COALESCE(feed,''), COALESCE(repaired,''), cert_expires_at, created_at`
+
+// scanFriends 将查询结果扫描为朋友切片。
+func scanFriends(rows *sql.Rows) ([]model.Friend, error) {
 	var out []model.Friend
 	for rows.Next() {
 		var f model.Friend
-		var createdAt sql.NullTime
-		if err := rows.Scan(&f.Name, &f.Link, &f.Avatar, &f.Error, &createdAt); err != nil {
+		var certExpires, createdAt sql.NullTime
+		if err := rows.Scan(&f.Name, &f.Link, &f.Avatar, &f.Error, &f.Feed, &f.Repaired, &certExpires, &createdAt); err != nil {
 			return nil, fmt.Errorf("scan friends: %w", err)
 		}
+		if certExpires.Valid {
+			t := certExpires.Time
+			f.CertExpiresAt = &t
+		}
 		if createdAt.Valid {
 			f.CreatedAt = createdAt.Time
 		} else {
@@ -132,18 +224,89 @@ func (s *SQLite) ListFriends(ctx context.Context) ([]model.Friend, error) {
 
 // ListPosts 返回全部文章，按 created 倒序。
 func (s *SQLite) ListPosts(ctx context.Context) ([]model.Post, error) {
-	rows, err := s.db.QueryContext(ctx, `SELECT title, created, updated, link, author, avatar, rule, created_at FROM posts ORDER BY created DESC`)
+	rows, err := s.db.QueryContext(ctx, `SELECT `+postColumns+` FROM posts ORDER BY created DESC`)
 	if err != nil {
 		return nil, fmt.Errorf("query posts: %w", err)
 	}
 	defer rows.Close()
+	return scanPosts(rows)
+}
+
+// PostQuery 为分页查询文章的条件：
+// - Sort：created（默认）或 updated，均为倒序
+// - Friend：按朋友链接或名称过滤，空表示不过滤
+// - Limit<=0 表示不限制条数
+type PostQuery struct {
+	Offset int
+	Limit  int
+	Sort   string
+	Friend string
+}
+
+// QueryPosts 按条件分页查询文章，同时返回满足条件的总数。
+func (s *SQLite) QueryPosts(ctx context.Context, q PostQuery) ([]model.Post, int, error) {
+	where := ""
+	var args []any
+	if q.Friend != "" {
+		where = ` WHERE friend_link = ? OR friend_link IN (SELECT link FROM friends WHERE name = ?)`
+		args = append(args, q.Friend, q.Friend)
+	}
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM posts`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count posts: %w", err)
+	}
+	order := "created"
+	if q.Sort == "updated" {
+		order = "updated"
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1 // SQLite 中 LIMIT -1 表示不限制
+	}
+	args = append(args, limit, max(0, q.Offset))
+	rows, err := s.db.QueryContext(ctx, `SELECT `+postColumns+` FROM posts`+where+` ORDER BY `+order+` DESC, link LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query posts: %w", err)
+	}
+	defer rows.Close()
+	out, err := scanPosts(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
+
+// RandomPosts 随机返回 n 篇文章。
+func (s *SQLite) RandomPosts(ctx context.Context, n int) ([]model.Post, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+postColumns+` FROM posts ORDER BY RANDOM() LIMIT ?`, max(1, n))
+	if err != nil {
+		return nil, fmt.Errorf("query random posts: %w", err)
+	}
+	defer rows.Close()
+	return scanPosts(rows)
+}
+
+// RandomFriends 随机返回 n 位状态正常（无错误）的朋友。
+func (s *SQLite) RandomFriends(ctx context.Context, n int) ([]model.Friend, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+friendColumns+` FROM friends WHERE (error IS NULL OR error = '') ORDER BY RANDOM() LIMIT ?`, max(1, n))
+	if err != nil {
+		return nil, fmt.Errorf("query random friends: %w", err)
+	}
+	defer rows.Close()
+	return scanFriends(rows)
+}
+
+const postColumns = `title, created, updated, link, author, avatar, rule, COALESCE(friend_link,''), created_at`
+
+// scanPosts 将查询结果扫描为文章切片，时间字段为空时做兜底。
+func scanPosts(rows *sql.Rows) ([]model.Post, error) {
 	var out []model.Post
 	for rows.Next() {
 		var p model.Post
 		var created sql.NullTime
 		var updated sql.NullTime
 		var createdAt sql.NullTime
-		if err := rows.Scan(&p.Title, &created, &updated, &p.Link, &p.Author, &p.Avatar, &p.Rule, &createdAt); err != nil {
+		if err := rows.Scan(&p.Title, &created, &updated, &p.Link, &p.Author, &p.Avatar, &p.Rule, &p.FriendLink, &createdAt); err != nil {
 			return nil, fmt.Errorf("scan posts: %w", err)
 		}
 		if created.Valid {
@@ -165,6 +328,146 @@ func (s *SQLite) ListPosts(ctx context.Context) ([]model.Post, error) {
 	return out, nil
 }
 
+// FeedCache 为朋友订阅地址的发现缓存。
+type FeedCache struct {
+	Link         string // 朋友链接
+	Feed         string // 发现的订阅地址
+	Strategy     string // 命中所用的发现策略（feed_suffix/path/link）
+	DiscoveredAt time.Time
+}
+
+// GetFeedCache 读取朋友的订阅发现缓存，不存在时返回 false。
+func (s *SQLite) GetFeedCache(ctx context.Context, link string) (FeedCache, bool, error) {
+	c := FeedCache{Link: link}
+	var at sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT feed, COALESCE(strategy,''), discovered_at FROM feed_cache WHERE link = ?`, link).
+		Scan(&c.Feed, &c.Strategy, &at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, false, nil
+	}
+	if err != nil {
+		return c, false, fmt.Errorf("get feed cache %s: %w", link, err)
+	}
+	if at.Valid {
+		c.DiscoveredAt = at.Time
+	}
+	return c, true, nil
+}
+
+// PutFeedCache 写入或覆盖朋友的订阅发现缓存。
+func (s *SQLite) PutFeedCache(ctx context.Context, c FeedCache) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO feed_cache(link, feed, strategy, discovered_at)
+        VALUES(?,?,?,?)
+        ON CONFLICT(link) DO UPDATE SET feed=excluded.feed, strategy=excluded.strategy, discovered_at=excluded.discovered_at`,
+		c.Link, c.Feed, c.Strategy, nowOr(c.DiscoveredAt))
+	if err != nil {
+		return fmt.Errorf("put feed cache %s: %w", c.Link, err)
+	}
+	return nil
+}
+
+// DeleteFeedCache 删除朋友的订阅发现缓存。
+func (s *SQLite) DeleteFeedCache(ctx context.Context, link string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM feed_cache WHERE link = ?`, link); err != nil {
+		return fmt.Errorf("delete feed cache %s: %w", link, err)
+	}
+	return nil
+}
+
+// FriendAliases 返回朋友的历史链接到当前链接的映射（由 MigrateFriend 记录）。
+func (s *SQLite) FriendAliases(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT alias, link FROM friend_aliases`)
+	if err != nil {
+		return nil, fmt.Errorf("query friend aliases: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var alias, link string
+		if err := rows.Scan(&alias, &link); err != nil {
+			return nil, fmt.Errorf("scan friend aliases: %w", err)
+		}
+		out[alias] = link
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate friend aliases: %w", err)
+	}
+	return out, nil
+}
+
+// MigrateFriend 将朋友从 from 迁移到 to（站点永久重定向）：
+// 朋友、文章与订阅缓存改用新链接，from 记为 to 的别名，原先指向 from 的别名一并改指 to。
+// 新链接已存在朋友时保留其记录并删除旧记录。
+func (s *SQLite) MigrateFriend(ctx context.Context, from, to string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("migrate friend %s: %w", from, err)
+	}
+	defer tx.Rollback()
+	stmts := []struct {
+		q    string
+		args []any
+	}{
+		{`DELETE FROM friend_aliases WHERE alias = ?`, []any{to}},
+		{`UPDATE friend_aliases SET link = ? WHERE link = ?`, []any{to, from}},
+		{`INSERT INTO friend_aliases(alias, link, migrated_at) VALUES(?,?,?)
+            ON CONFLICT(alias) DO UPDATE SET link=excluded.link, migrated_at=excluded.migrated_at`, []any{from, to, time.Now()}},
+		{`DELETE FROM friends WHERE link = ? AND EXISTS (SELECT 1 FROM friends WHERE link = ?)`, []any{from, to}},
+		{`UPDATE friends SET link = ? WHERE link = ?`, []any{to, from}},
+		{`UPDATE posts SET friend_link = ? WHERE friend_link = ?`, []any{to, from}},
+		{`DELETE FROM feed_cache WHERE link = ? AND EXISTS (SELECT 1 FROM feed_cache WHERE link = ?)`, []any{from, to}},
+		{`UPDATE feed_cache SET link = ? WHERE link = ?`, []any{to, from}},
+	}
+	for _, st := range stmts {
+		if _, err := tx.ExecContext(ctx, st.q, st.args...); err != nil {
+			return fmt.Errorf("migrate friend %s: %w", from, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migrate friend %s: %w", from, err)
+	}
+	return nil
+}
+
+// LoadValidator 读取 URL 的条件请求校验值（实现 fetch.ValidatorStore），不存在时返回 false。
+func (s *SQLite) LoadValidator(ctx context.Context, url string) (model.Validator, bool, error) {
+	var v model.Validator
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(etag,''), COALESCE(last_modified,'') FROM http_validators WHERE url = ?`, url).
+		Scan(&v.ETag, &v.LastModified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Validator{}, false, nil
+	}
+	if err != nil {
+		return model.Validator{}, false, fmt.Errorf("load validator %s: %w", url, err)
+	}
+	return v, true, nil
+}
+
+// FriendRepaired 返回朋友上次记录的订阅修复项（逗号分隔），朋友不存在时返回空串。
+func (s *SQLite) FriendRepaired(ctx context.Context, link string) (string, error) {
+	var repaired string
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(repaired,'') FROM friends WHERE link = ?`, link).Scan(&repaired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get friend repaired %s: %w", link, err)
+	}
+	return repaired, nil
+}
+
+// SaveValidator 写入 URL 的条件请求校验值（实现 fetch.ValidatorStore）。
+func (s *SQLite) SaveValidator(ctx context.Context, url string, v model.Validator) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO http_validators(url, etag, last_modified, updated_at)
+        VALUES(?,?,?,?)
+        ON CONFLICT(url) DO UPDATE SET etag=excluded.etag, last_modified=excluded.last_modified, updated_at=excluded.updated_at`,
+		url, v.ETag, v.LastModified, time.Now())
+	if err != nil {
+		return fmt.Errorf("save validator %s: %w", url, err)
+	}
+	return nil
+}
+
 // Stats 统计汇总：朋友总数/活跃数/异常数、文章总数、更新时间。
 func (s *SQLite) Stats(ctx context.Context) (model.Stats, error) {
 	var st model.Stats
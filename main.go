@@ -1,133 +1,91 @@
-// 命令行入口：
-// - 解析 flags 与 settings.yaml/rules.yaml
-// - 初始化日志、HTTP 客户端、数据库
-// - 支持友链页发现调试（-discover）与极简导出（data.json）
+// 命令行入口（子命令形式）：
+//
+//	cof run       聚合友链文章并导出 data.json（默认命令，可加 -daemon 常驻）
+//	cof discover  打印 LINK 来源解析到的朋友，用于调试 rules.yaml
+//	cof probe     对单个站点执行订阅发现与解析
+//	cof export    从已有数据库重新生成 data.json（可选 OPML），不抓取
+//	cof serve     基于数据库提供只读 HTTP 接口
+//	cof db        数据库维护（vacuum|reset|stats）
+//
+// 各子命令共享配置/规则/日志/HTTP 客户端的初始化逻辑（见 bootstrap.go）。
 package main
 
 import (
-	"context"
-	"flag"
-	"log"
+	"fmt"
 	"os"
-	"time"
-
-	"go-circle-of-friends/internal/aggregate"
-	"go-circle-of-friends/internal/config"
-	"go-circle-of-friends/internal/export"
-	"go-circle-of-friends/internal/fetch"
-	"go-circle-of-friends/internal/friends"
-	"go-circle-of-friends/internal/logx"
-	"go-circle-of-friends/internal/rules"
-	"go-circle-of-friends/internal/store"
+	"strings"
 )
 
-func main() {
-	var (
-		configPath = flag.String("config", "settings.yaml", "path to settings.yaml")
-		rulesPath  = flag.String("rules", "rules.yaml", "path to rules.yaml (optional)")
-		exportPath = flag.String("export", "data.json", "export json path when SIMPLE_MODE=true")
-		discover   = flag.Bool("discover", false, "print discovered friends from LINK page sources and exit")
-	)
-	flag.Parse()
+// command 描述一个子命令。
+type command struct {
+	name  string
+	usage string // 参数说明，如 "[flags] <url>"
+	short string // 一句话帮助
+	run   func(args []string) error
+}
 
-	// 1) 加载配置与规则
-	cfg, err := config.Load(*configPath)
-	if err != nil {
-		log.Fatalf("load config: %v", err)
-	}
-	var rl *rules.Rules
-	if *rulesPath != "" {
-		if r, err := rules.Load(*rulesPath); err == nil {
-			rl = r
-		} else {
-			log.Printf("load rules failed: %v", err)
-		}
-	}
-	// 2) 初始化日志：级别/格式/语言/颜色
-	logx.Init(cfg.LogLevel, cfg.LogFormat, cfg.LogLocale, cfg.LogColor)
+var commands []command
 
-	// 3) 初始化 HTTP 客户端（含代理与重试）
-	cl, err := fetch.New(fetch.Options{
-		ProxyHTTP:  cfg.Proxy.HTTP,
-		ProxyHTTPS: cfg.Proxy.HTTPS,
-		Timeout:    25 * time.Second,
-		Retry:      cfg.Concurrency.Retry,
-	})
-	if err != nil {
-		log.Fatalf("http client: %v", err)
+func init() {
+	// 在 init 中注册，避免 help 与命令表互相引用造成初始化循环
+	commands = []command{
+		{name: "run", usage: "[flags]", short: "aggregate posts from all friends and export data.json", run: cmdRun},
+		{name: "discover", usage: "[flags]", short: "print friends parsed from LINK sources and exit", run: cmdDiscover},
+		{name: "probe", usage: "[flags] <url>", short: "discover and parse the feed of a single site", run: cmdProbe},
+		{name: "export", usage: "[flags]", short: "regenerate data.json (and optionally OPML) from an existing database without crawling", run: cmdExport},
+		{name: "serve", usage: "[flags]", short: "serve the aggregated data in DATABASE over HTTP", run: cmdServe},
+		{name: "db", usage: "[flags] vacuum|reset|stats", short: "database maintenance", run: cmdDB},
+		{name: "help", usage: "[command]", short: "show help for a command", run: cmdHelp},
 	}
+}
 
-	ctx := context.Background()
-	if *discover {
-		// 4) 调试：仅解析友链页并打印结果后退出
-		total := 0
-		for _, src := range cfg.LinkSources {
-			if src.Type != "page" {
-				continue
-			}
-			var preset rules.Preset
-			if rl != nil {
-				if p, ok := rl.GetPreset(src.Theme); ok {
-					preset = p
-				}
-			}
-			list, err := friends.ParseFriendsPage(ctx, cl, src.URL, preset)
-			if err != nil {
-				logx.Errorf("解析友链页失败：%s 错误=%v", src.URL, err)
-				continue
-			}
-			logx.Infof("%s 解析到 %d 位朋友", src.URL, len(list))
-			for _, f := range list {
-				logx.Infof("- 名称=%q 链接=%s 头像=%s", f.Name, f.Link, f.Avatar)
-			}
-			total += len(list)
-		}
-		if total == 0 {
-			logx.Warnf("未从页面来源发现朋友，请检查 LINK.url 与 rules.yaml 选择器。")
-		}
-		return
+func main() {
+	args := os.Args[1:]
+	// 兼容旧用法：无子命令或直接以 flag 开头时视为 run
+	name := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-
-	// 5) 数据存储：极简模式不打开数据库；正常模式打开并按需重置
-	var st *store.SQLite
-	if !cfg.SimpleMode {
-		var err error
-		st, err = store.OpenSQLite(cfg.Database.DSN)
-		if err != nil {
-			log.Fatalf("open db: %v", err)
-		}
-		defer st.Close()
-		if cfg.ResetOnStart {
-			if err := st.Reset(ctx); err != nil {
-				logx.Warnf("启动清理数据库失败：%v", err)
-			} else {
-				logx.Infof("已清理数据库表（friends/posts）")
-			}
-		}
-	} else if cfg.ResetOnStart {
-		// 极简模式仅删除导出文件，不操作数据库
-		logx.Infof("极简模式：跳过数据库打开与清理")
+	cmd, ok := lookup(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "cof: unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
+	if err := cmd.run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "cof %s: %v\n", cmd.name, err)
+		os.Exit(1)
 	}
-	if cfg.ResetOnStart && *exportPath != "" {
-		if err := os.Remove(*exportPath); err == nil {
-			logx.Infof("已删除导出文件：%s", *exportPath)
+}
+
+func lookup(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
 		}
 	}
+	return command{}, false
+}
 
-	// 6) 运行聚合流程
-	run := aggregate.New(cfg, st, cl, rl)
-	logx.Infof("开始聚合：极简模式=%v", cfg.SimpleMode)
-	if err := run.Run(ctx); err != nil {
-		logx.Errorf("运行失败：%v", err)
-		os.Exit(1)
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: cof <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.short)
 	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `run "cof help <command>" for command flags`)
+}
 
-	if cfg.SimpleMode {
-		// 7) 极简导出：只导出 JSON，跳过写库
-		fr, ps := run.BufferData()
-		if err := export.ToJSONData(ctx, fr, ps, *exportPath); err != nil {
-			log.Fatalf("export json: %v", err)
-		}
-		logx.Infof("已导出 %s", *exportPath)
+func cmdHelp(args []string) error {
+	if len(args) == 0 {
+		printUsage()
+		return nil
+	}
+	c, ok := lookup(args[0])
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
 	}
+	return c.run([]string{"-h"})
 }
@@ -0,0 +1,69 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+    store "go-circle-of-friends/internal/store"
+)
+
+func TestAggregate_ReusesCachedFeedAndRediscoversOnFailure(t *testing.T) {
+    var probes int32
+    var feedPath atomic.Value
+    feedPath.Store("/atom.xml")
+    mux := http.NewServeMux()
+    mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&probes, 1)
+        if r.URL.Path == feedPath.Load().(string) {
+            w.Header().Set("Content-Type", "application/atom+xml")
+            _, _ = w.Write([]byte(atomSample))
+            return
+        }
+        http.NotFound(w, r)
+    })
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "c.db"))
+    if err != nil { t.Fatalf("open: %v", err) }
+    defer st.Close()
+    ctx := context.Background()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second})
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "c", Link: srv.URL + "/"}},
+        Concurrency:   config.Concurrency{Fetch: 1, Probe: 1},
+        FeedCacheTTL:  time.Hour,
+    }
+
+    // 第一次：完整发现并写入缓存
+    if err := aggregate.New(cfg, st, cl, nil).Run(ctx); err != nil { t.Fatalf("run1: %v", err) }
+    c, ok, err := st.GetFeedCache(ctx, srv.URL+"/")
+    if err != nil || !ok || c.Feed != srv.URL+"/atom.xml" || c.Strategy != "path" { t.Fatalf("cache after run1: %+v ok=%v err=%v", c, ok, err) }
+    fr, _ := st.ListFriends(ctx)
+    if len(fr) != 1 || fr[0].Feed != c.Feed { t.Fatalf("friend feed not stored: %+v", fr) }
+
+    // 第二次：直接使用缓存，只请求订阅本身
+    atomic.StoreInt32(&probes, 0)
+    if err := aggregate.New(cfg, st, cl, nil).Run(ctx); err != nil { t.Fatalf("run2: %v", err) }
+    if n := atomic.LoadInt32(&probes); n != 1 { t.Fatalf("requests with cached feed=%d want=1", n) }
+
+    // 订阅迁移：缓存地址失效后回退到完整发现并更新缓存
+    feedPath.Store("/rss.xml")
+    if err := aggregate.New(cfg, st, cl, nil).Run(ctx); err != nil { t.Fatalf("run3: %v", err) }
+    c, ok, _ = st.GetFeedCache(ctx, srv.URL+"/")
+    if !ok || c.Feed != srv.URL+"/rss.xml" { t.Fatalf("cache not refreshed: %+v", c) }
+
+    // 缓存过期：即使地址仍有效也重新发现
+    _ = st.PutFeedCache(ctx, store.FeedCache{Link: srv.URL + "/", Feed: srv.URL + "/rss.xml", DiscoveredAt: time.Now().Add(-2 * time.Hour)})
+    atomic.StoreInt32(&probes, 0)
+    if err := aggregate.New(cfg, st, cl, nil).Run(ctx); err != nil { t.Fatalf("run4: %v", err) }
+    if n := atomic.LoadInt32(&probes); n <= 2 { t.Fatalf("expired cache should trigger discovery, requests=%d", n) }
+}
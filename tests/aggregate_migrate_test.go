@@ -0,0 +1,107 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/rules"
+    store "go-circle-of-friends/internal/store"
+)
+
+func TestAggregate_MigratesMovedFriend(t *testing.T) {
+    now := time.Now().UTC().Format(time.RFC1123)
+    newSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/" { _, _ = w.Write([]byte(`<html><body>home</body></html>`)); return }
+        if r.URL.Path != "/index.xml" { http.NotFound(w, r); return }
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>moved</title>
+        <item><title>p</title><link>http://ex/moved-p</link><pubDate>` + now + `</pubDate></item></channel></rss>`))
+    }))
+    defer newSrv.Close()
+    // 旧域名的所有路径都永久重定向到新域名
+    oldSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        http.Redirect(w, r, newSrv.URL+r.URL.Path, http.StatusMovedPermanently)
+    }))
+    defer oldSrv.Close()
+
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "t.db"))
+    if err != nil { t.Fatalf("open sqlite: %v", err) }
+    defer st.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second})
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "moved", Link: oldSrv.URL, FeedSuffix: "/index.xml"}},
+        Concurrency: config.Concurrency{Fetch: 1},
+        FeedCacheTTL: time.Hour,
+    }
+    ctx := context.Background()
+    for i := 0; i < 2; i++ {
+        if err := aggregate.New(cfg, st, cl, &rules.Rules{}).Run(ctx); err != nil { t.Fatalf("run %d: %v", i, err) }
+        fs, err := st.ListFriends(ctx)
+        if err != nil { t.Fatalf("list friends: %v", err) }
+        // 第二轮配置中仍是旧链接，按别名去重，不产生重复朋友
+        if len(fs) != 1 || fs[0].Link != newSrv.URL || fs[0].Feed != newSrv.URL+"/index.xml" { t.Fatalf("run %d friends=%+v", i, fs) }
+    }
+    aliases, err := st.FriendAliases(ctx)
+    if err != nil { t.Fatalf("aliases: %v", err) }
+    if aliases[oldSrv.URL] != newSrv.URL { t.Fatalf("aliases=%v", aliases) }
+    posts, _ := st.ListPosts(ctx)
+    if len(posts) != 1 || posts[0].FriendLink != newSrv.URL { t.Fatalf("posts=%+v", posts) }
+    c, ok, _ := st.GetFeedCache(ctx, newSrv.URL)
+    if !ok || c.Feed != newSrv.URL+"/index.xml" { t.Fatalf("feed cache=%+v ok=%v", c, ok) }
+}
+
+func TestStore_MigrateFriendCollapsesAliases(t *testing.T) {
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "t.db"))
+    if err != nil { t.Fatalf("open sqlite: %v", err) }
+    defer st.Close()
+    ctx := context.Background()
+    if err := st.MigrateFriend(ctx, "http://a", "http://b"); err != nil { t.Fatalf("migrate: %v", err) }
+    if err := st.MigrateFriend(ctx, "http://b", "http://c"); err != nil { t.Fatalf("migrate: %v", err) }
+    m, _ := st.FriendAliases(ctx)
+    if len(m) != 2 || m["http://a"] != "http://c" || m["http://b"] != "http://c" { t.Fatalf("aliases=%v", m) }
+    // 迁回旧地址时去掉指向自身的别名
+    if err := st.MigrateFriend(ctx, "http://c", "http://a"); err != nil { t.Fatalf("migrate: %v", err) }
+    m, _ = st.FriendAliases(ctx)
+    if _, ok := m["http://a"]; ok || m["http://b"] != "http://a" || m["http://c"] != "http://a" { t.Fatalf("aliases=%v", m) }
+}
+
+func TestAggregate_FeedMovedToThirdPartyKeepsLink(t *testing.T) {
+    now := time.Now().UTC().Format(time.RFC1123)
+    feedHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>burned</title>
+        <item><title>p</title><link>http://ex/burned-p</link><pubDate>` + now + `</pubDate></item></channel></rss>`))
+    }))
+    defer feedHost.Close()
+    // 只有订阅永久重定向到第三方主机，首页本身未迁移
+    site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/index.xml" { http.Redirect(w, r, feedHost.URL+"/blog", http.StatusMovedPermanently); return }
+        _, _ = w.Write([]byte(`<html><body>home</body></html>`))
+    }))
+    defer site.Close()
+
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "t.db"))
+    if err != nil { t.Fatalf("open sqlite: %v", err) }
+    defer st.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second})
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "burned", Link: site.URL, FeedSuffix: "/index.xml"}},
+        Concurrency: config.Concurrency{Fetch: 1},
+        FeedCacheTTL: time.Hour,
+    }
+    ctx := context.Background()
+    if err := aggregate.New(cfg, st, cl, &rules.Rules{}).Run(ctx); err != nil { t.Fatalf("run: %v", err) }
+    fs, _ := st.ListFriends(ctx)
+    if len(fs) != 1 || fs[0].Link != site.URL || fs[0].Feed != feedHost.URL+"/blog" { t.Fatalf("friends=%+v", fs) }
+    aliases, _ := st.FriendAliases(ctx)
+    if len(aliases) != 0 { t.Fatalf("aliases=%v", aliases) }
+    posts, _ := st.ListPosts(ctx)
+    if len(posts) != 1 || posts[0].FriendLink != site.URL { t.Fatalf("posts=%+v", posts) }
+}
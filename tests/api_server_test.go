@@ -0,0 +1,62 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/api"
+    "go-circle-of-friends/internal/model"
+    store "go-circle-of-friends/internal/store"
+)
+
+func TestAPI_PostsPagingSortAndFilter(t *testing.T) {
+    s, err := store.OpenSQLite(filepath.Join(t.TempDir(), "api.db"))
+    if err != nil { t.Fatalf("open: %v", err) }
+    defer s.Close()
+    ctx := context.Background()
+
+    _ = s.UpsertFriend(ctx, model.Friend{Name: "A", Link: "https://a"})
+    _ = s.UpsertFriend(ctx, model.Friend{Name: "B", Link: "https://b", Error: "x"})
+    now := time.Now()
+    for i, l := range []string{"p1", "p2", "p3"} {
+        p := model.Post{Title: l, Link: l, FriendLink: "https://a", Created: now.Add(time.Duration(i) * time.Hour), Updated: now.Add(-time.Duration(i) * time.Hour)}
+        if err := s.UpsertPost(ctx, p); err != nil { t.Fatalf("seed: %v", err) }
+    }
+    _ = s.UpsertPost(ctx, model.Post{Title: "q1", Link: "q1", FriendLink: "https://b", Created: now.Add(-time.Hour)})
+
+    srv := httptest.NewServer(api.New(s))
+    defer srv.Close()
+
+    var pp api.PostsPage
+    getJSON(t, srv.URL+"/posts?page=1&size=2", &pp)
+    if pp.Total != 4 || len(pp.Posts) != 2 || pp.Posts[0].Title != "p3" { t.Fatalf("paging: %+v", pp) }
+
+    getJSON(t, srv.URL+"/posts?sort=updated&friend=A", &pp)
+    if pp.Total != 3 || pp.Posts[0].Title != "p1" { t.Fatalf("sort/filter: %+v", pp) }
+
+    var all api.AllResponse
+    getJSON(t, srv.URL+"/all?size=1", &all)
+    if all.Stats.FriendsTotal != 2 || len(all.Friends) != 2 || len(all.Posts) != 1 { t.Fatalf("all: %+v", all) }
+
+    var rf []model.Friend
+    getJSON(t, srv.URL+"/randomfriend?num=5", &rf)
+    if len(rf) != 1 || rf[0].Name != "A" { t.Fatalf("random friend should skip errored: %+v", rf) }
+
+    var rp []model.Post
+    getJSON(t, srv.URL+"/randompost?num=2", &rp)
+    if len(rp) != 2 { t.Fatalf("random posts=%d want=2", len(rp)) }
+}
+
+func getJSON(t *testing.T, u string, v any) {
+    t.Helper()
+    resp, err := http.Get(u)
+    if err != nil { t.Fatalf("get %s: %v", u, err) }
+    defer resp.Body.Close()
+    if resp.StatusCode != 200 { t.Fatalf("get %s: status %d", u, resp.StatusCode) }
+    if err := json.NewDecoder(resp.Body).Decode(v); err != nil { t.Fatalf("decode %s: %v", u, err) }
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "sync"
+    "syscall"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/model"
+    store "go-circle-of-friends/internal/store"
+)
+
+var (
+    cliOnce sync.Once
+    cliPath string
+    cliErr  error
+)
+
+// buildCLI 编译一次 cof 可执行文件，供子命令测试以独立进程运行。
+func buildCLI(t *testing.T) string {
+    t.Helper()
+    cliOnce.Do(func() {
+        dir, err := os.MkdirTemp("", "cof-cli")
+        if err != nil { cliErr = err; return }
+        cliPath = filepath.Join(dir, "cof")
+        out, err := exec.Command("go", "build", "-o", cliPath, "..").CombinedOutput()
+        if err != nil { cliErr = err; cliPath = string(out) }
+    })
+    if cliErr != nil { t.Fatalf("build cof: %v\n%s", cliErr, cliPath) }
+    return cliPath
+}
+
+// writeCLIConfig 写入只含数据库路径的最小 settings.yaml。
+func writeCLIConfig(t *testing.T, dir, dsn string) string {
+    t.Helper()
+    path := filepath.Join(dir, "settings.yaml")
+    cfg := "SIMPLE_MODE: false\nDATABASE:\n  type: sqlite\n  dsn: " + dsn + "\n"
+    if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil { t.Fatalf("write config: %v", err) }
+    return path
+}
+
+// seedCLIStore 创建含一个正常朋友（带订阅）、一个异常朋友与一篇文章的数据库。
+func seedCLIStore(t *testing.T, dsn string) {
+    t.Helper()
+    st, err := store.OpenSQLite(dsn)
+    if err != nil { t.Fatalf("open: %v", err) }
+    defer st.Close()
+    ctx := context.Background()
+    _ = st.UpsertFriend(ctx, model.Friend{Name: "Alive", Link: "https://alive.example", Feed: "https://alive.example/atom.xml"})
+    _ = st.UpsertFriend(ctx, model.Friend{Name: "Broken", Link: "https://broken.example", Error: "no feed discovered"})
+    if err := st.UpsertPost(ctx, model.Post{Title: "hello", Link: "https://alive.example/hello", FriendLink: "https://alive.example", Created: time.Now()}); err != nil { t.Fatalf("seed post: %v", err) }
+}
+
+func TestCLI_ExportFromExistingDatabase(t *testing.T) {
+    bin := buildCLI(t)
+    dir := t.TempDir()
+    dsn := filepath.Join(dir, "data.db")
+    seedCLIStore(t, dsn)
+    cfg := writeCLIConfig(t, dir, dsn)
+    jsonPath, opmlPath := filepath.Join(dir, "out.json"), filepath.Join(dir, "out.opml")
+
+    cmd := exec.Command(bin, "export", "-config", cfg, "-rules", "", "-export", jsonPath, "-opml", opmlPath)
+    if out, err := cmd.CombinedOutput(); err != nil { t.Fatalf("export: %v\n%s", err, out) }
+    b, err := os.ReadFile(jsonPath)
+    if err != nil { t.Fatalf("read json: %v", err) }
+    var ex model.Export
+    if err := json.Unmarshal(b, &ex); err != nil { t.Fatalf("decode json: %v", err) }
+    if ex.Stats.FriendsTotal != 2 || ex.Stats.FriendsAlive != 1 || len(ex.Posts) != 1 || ex.Posts[0].Title != "hello" { t.Fatalf("export=%+v", ex) }
+    o, err := os.ReadFile(opmlPath)
+    if err != nil { t.Fatalf("read opml: %v", err) }
+    if !strings.Contains(string(o), "https://alive.example/atom.xml") || strings.Contains(string(o), "broken.example") { t.Fatalf("opml=%s", o) }
+
+    // 数据库不存在时报错，且不会隐式建库
+    missing := filepath.Join(dir, "missing.db")
+    cmd = exec.Command(bin, "export", "-config", writeCLIConfig(t, t.TempDir(), missing), "-rules", "", "-export", jsonPath)
+    if out, err := cmd.CombinedOutput(); err == nil { t.Fatalf("export from missing db should fail:\n%s", out) }
+    if _, err := os.Stat(missing); !os.IsNotExist(err) { t.Fatalf("missing db was created: %v", err) }
+}
+
+func TestCLI_ServeRequiresExistingDatabase(t *testing.T) {
+    bin := buildCLI(t)
+    dir := t.TempDir()
+
+    // 数据库不存在时直接报错退出，不创建空库
+    missing := filepath.Join(dir, "missing.db")
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    cmd := exec.CommandContext(ctx, bin, "serve", "-config", writeCLIConfig(t, t.TempDir(), missing), "-rules", "", "-addr", "127.0.0.1:0")
+    out, err := cmd.CombinedOutput()
+    if ctx.Err() != nil { t.Fatalf("serve with missing db kept running:\n%s", out) }
+    if err == nil { t.Fatalf("serve with missing db should fail:\n%s", out) }
+    if _, err := os.Stat(missing); !os.IsNotExist(err) { t.Fatalf("missing db was created: %v", err) }
+
+    // 已有数据库时提供接口，收到 SIGTERM 后正常退出
+    dsn := filepath.Join(dir, "data.db")
+    seedCLIStore(t, dsn)
+    l, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil { t.Fatalf("listen: %v", err) }
+    addr := l.Addr().String()
+    l.Close()
+    cmd = exec.Command(bin, "serve", "-config", writeCLIConfig(t, dir, dsn), "-rules", "", "-addr", addr)
+    if err := cmd.Start(); err != nil { t.Fatalf("start serve: %v", err) }
+    defer cmd.Process.Kill()
+    var body []byte
+    for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+        resp, err := http.Get("http://" + addr + "/friends")
+        if err != nil { continue }
+        body, _ = io.ReadAll(resp.Body)
+        resp.Body.Close()
+        break
+    }
+    if !strings.Contains(string(body), "https://alive.example") { t.Fatalf("friends=%s", body) }
+    if err := cmd.Process.Signal(syscall.SIGTERM); err != nil { t.Fatalf("signal: %v", err) }
+    if err := cmd.Wait(); err != nil { t.Fatalf("serve exit: %v", err) }
+}
+
+func TestCLI_ProbeAcceptsFlagsAfterURL(t *testing.T) {
+    bin := buildCLI(t)
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/atom.xml" { http.NotFound(w, r); return }
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>t</title>
+        <item><title>first</title><link>https://ex/1</link></item><item><title>second</title><link>https://ex/2</link></item></channel></rss>`))
+    }))
+    defer srv.Close()
+    dir := t.TempDir()
+    cfg := filepath.Join(dir, "settings.yaml")
+    if err := os.WriteFile(cfg, []byte("ALLOW_PRIVATE_NETWORKS: true\n"), 0o644); err != nil { t.Fatalf("write config: %v", err) }
+    for _, args := range [][]string{
+        {"probe", srv.URL, "-items", "1", "-feed-suffix", "/atom.xml", "-config", cfg, "-rules", ""},
+        {"probe", "-items", "1", "-feed-suffix", "/atom.xml", "-config", cfg, "-rules", "", srv.URL},
+    } {
+        out, err := exec.Command(bin, args...).CombinedOutput()
+        if err != nil { t.Fatalf("%v: %v\n%s", args, err, out) }
+        if !strings.Contains(string(out), "items: 1") || strings.Contains(string(out), "second") { t.Fatalf("%v output:\n%s", args, out) }
+    }
+    if out, err := exec.Command(bin, "probe", "-config", cfg, srv.URL, "extra").CombinedOutput(); err == nil { t.Fatalf("two urls should fail:\n%s", out) }
+}
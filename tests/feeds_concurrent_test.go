@@ -0,0 +1,51 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/feeds"
+    "go-circle-of-friends/internal/fetch"
+)
+
+func TestDiscoverFeed_ConcurrentKeepsPriorityAndCancels(t *testing.T) {
+    var hung, cancelled int32
+    mux := http.NewServeMux()
+    // 最高优先级：较慢但有效
+    mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+        time.Sleep(200 * time.Millisecond)
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(rssSample))
+    })
+    // 次优先级：立即返回有效订阅，但不应胜出
+    mux.HandleFunc("/atom.xml", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/atom+xml")
+        _, _ = w.Write([]byte(atomSample))
+    })
+    // 其余候选一直挂起，直到被取消
+    mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&hung, 1)
+        <-r.Context().Done()
+        atomic.AddInt32(&cancelled, 1)
+    })
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+
+    cl, _ := fetch.New(fetch.Options{})
+    start := time.Now()
+    got, tr, err := feeds.DiscoverFeedTrace(context.Background(), cl, srv.URL+"/", "", feeds.WithConcurrency(4))
+    if err != nil { t.Fatalf("discover: %v", err) }
+    if want := srv.URL + "/index.xml"; got != want { t.Fatalf("got %q want %q", got, want) }
+    if d := time.Since(start); d > 3*time.Second { t.Fatalf("outstanding probes not cancelled, took %s", d) }
+    if tr.Attempts[0].URL != got || !tr.Attempts[0].OK { t.Fatalf("attempts not in priority order: %+v", tr.Attempts[0]) }
+    if atomic.LoadInt32(&hung) == 0 { t.Fatalf("expect concurrent probes to start") }
+    deadline := time.Now().Add(2 * time.Second)
+    for atomic.LoadInt32(&cancelled) < atomic.LoadInt32(&hung) && time.Now().Before(deadline) {
+        time.Sleep(10 * time.Millisecond)
+    }
+    if c, h := atomic.LoadInt32(&cancelled), atomic.LoadInt32(&hung); c != h { t.Fatalf("cancelled=%d hung=%d", c, h) }
+}
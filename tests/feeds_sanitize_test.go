@@ -0,0 +1,96 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "reflect"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/feeds"
+    "go-circle-of-friends/internal/fetch"
+    store "go-circle-of-friends/internal/store"
+)
+
+func TestSanitize_RepairsKnownDefects(t *testing.T) {
+    cases := []struct {
+        name    string
+        in      string
+        want    string
+        repairs []string
+    }{
+        {"valid", `<rss><title>a &amp; b &#160;</title></rss>`, `<rss><title>a &amp; b &#160;</title></rss>`, nil},
+        {"bom_and_whitespace", "\n\xef\xbb\xbf<rss/>", `<rss/>`, []string{feeds.RepairLeadingWhitespace, feeds.RepairBOM}},
+        {"leading_garbage", "Warning: x\n<rss/>", `<rss/>`, []string{feeds.RepairLeadingGarbage, feeds.RepairLeadingWhitespace}},
+        {"control_chars", "<t>a\x01b\x1b\tc</t>", "<t>ab\tc</t>", []string{feeds.RepairControlChars}},
+        {"bare_ampersand", `<t>a & b&c=d</t>`, `<t>a &amp; b&amp;c=d</t>`, []string{feeds.RepairBareAmpersand}},
+        {"html_entities", `<t>a&nbsp;b&hellip;</t>`, `<t>a&#160;b&#8230;</t>`, []string{feeds.RepairHTMLEntities}},
+        {"unknown_entity", `<t>&foo;</t>`, `<t>&amp;foo;</t>`, []string{feeds.RepairBareAmpersand}},
+        {"cdata_untouched", `<t><![CDATA[a & b&nbsp;]]></t>`, `<t><![CDATA[a & b&nbsp;]]></t>`, nil},
+        {"json_feed", `  {"version":"https://jsonfeed.org/version/1"}`, `  {"version":"https://jsonfeed.org/version/1"}`, nil},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            got, repairs := feeds.Sanitize([]byte(c.in))
+            if string(got) != c.want { t.Fatalf("out=%q want=%q", got, c.want) }
+            if !reflect.DeepEqual(repairs, c.repairs) { t.Fatalf("repairs=%v want=%v", repairs, c.repairs) }
+        })
+    }
+}
+
+// brokenFeed 含控制字符、裸 & 与 HTML 实体，gofeed 直接解析会失败。
+const brokenFeed = "\n<?xml version=\"1.0\" encoding=\"utf-8\"?><rss version=\"2.0\"><channel><title>T</title>" +
+    "<item><title>a\x01 &amp; b&nbsp;c & d</title><link>https://example.com/p1</link></item></channel></rss>"
+
+func TestSanitize_ParseFeedDetailReportsRepairs(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(brokenFeed))
+    }))
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second})
+    res, err := feeds.ParseFeedDetail(context.Background(), cl, srv.URL, 0)
+    if err != nil { t.Fatalf("parse: %v", err) }
+    if len(res.Items) != 1 || res.Items[0].Title != "a & b\u00a0c & d" { t.Fatalf("items=%+v", res.Items) }
+    want := []string{feeds.RepairLeadingWhitespace, feeds.RepairControlChars, feeds.RepairHTMLEntities, feeds.RepairBareAmpersand}
+    if !reflect.DeepEqual(res.Repairs, want) { t.Fatalf("repairs=%v want=%v", res.Repairs, want) }
+}
+
+func TestSanitize_RunnerRecordsRepairedFriend(t *testing.T) {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/broken/index.xml", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(brokenFeed))
+    })
+    mux.HandleFunc("/fine/index.xml", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(rssSample))
+    })
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "r.db"))
+    if err != nil { t.Fatalf("open: %v", err) }
+    defer st.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second})
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{
+            {Name: "broken", Link: srv.URL + "/broken", FeedSuffix: "/index.xml"},
+            {Name: "fine", Link: srv.URL + "/fine", FeedSuffix: "/index.xml"},
+        },
+        Concurrency: config.Concurrency{Fetch: 2, Probe: 1},
+    }
+    ctx := context.Background()
+    if err := aggregate.New(cfg, st, cl, nil).Run(ctx); err != nil { t.Fatalf("run: %v", err) }
+    fr, _ := st.ListFriends(ctx)
+    got := map[string]string{}
+    for _, f := range fr { got[f.Name] = f.Repaired }
+    if got["broken"] != "leading_whitespace,control_chars,html_entities,bare_ampersand" || got["fine"] != "" {
+        t.Fatalf("repaired=%v", got)
+    }
+    ps, _ := st.ListPosts(ctx)
+    if len(ps) != 2 { t.Fatalf("posts=%d want=2", len(ps)) }
+}
@@ -0,0 +1,43 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "go-circle-of-friends/internal/feeds"
+    "go-circle-of-friends/internal/fetch"
+)
+
+func TestDiscoverFeedTrace_RecordsAttemptsAndLinkTags(t *testing.T) {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/" { http.NotFound(w, r); return }
+        w.Header().Set("Content-Type", "text/html")
+        _, _ = w.Write([]byte(`<!doctype html><head><link rel="alternate" type="application/atom+xml" href="/my.atom"><link rel="stylesheet" href="/a.css"></head>`))
+    })
+    mux.HandleFunc("/my.atom", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/atom+xml")
+        _, _ = w.Write([]byte(atomSample))
+    })
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+
+    cl, _ := fetch.New(fetch.Options{})
+    got, tr, err := feeds.DiscoverFeedTrace(context.Background(), cl, srv.URL+"/", "")
+    if err != nil { t.Fatalf("discover: %v", err) }
+    if got != srv.URL+"/my.atom" || tr.Feed != got { t.Fatalf("feed=%q trace.feed=%q", got, tr.Feed) }
+    if len(tr.LinkTags) != 1 || tr.LinkTags[0].Href != srv.URL+"/my.atom" { t.Fatalf("link tags: %+v", tr.LinkTags) }
+
+    last := tr.Attempts[len(tr.Attempts)-1]
+    if !last.OK || last.Source != "link" || last.Status != 200 || last.ContentType != "application/atom+xml" {
+        t.Fatalf("last attempt: %+v", last)
+    }
+    var saw404 bool
+    for _, a := range tr.Attempts[:len(tr.Attempts)-1] {
+        if a.OK { t.Fatalf("unexpected ok candidate: %+v", a) }
+        if a.Status == 404 { saw404 = true }
+    }
+    if !saw404 { t.Fatalf("expect 404 status recorded for missing candidates") }
+}
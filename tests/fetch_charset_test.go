@@ -0,0 +1,97 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "golang.org/x/text/encoding"
+    "golang.org/x/text/encoding/simplifiedchinese"
+    "golang.org/x/text/encoding/traditionalchinese"
+
+    "go-circle-of-friends/internal/feeds"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/friends"
+    "go-circle-of-friends/internal/rules"
+)
+
+func encodeTo(t *testing.T, enc encoding.Encoding, s string) []byte {
+    b, err := enc.NewEncoder().Bytes([]byte(s))
+    if err != nil { t.Fatalf("encode: %v", err) }
+    return b
+}
+
+func TestCharset_FriendsPageMetaGBK(t *testing.T) {
+    page := encodeTo(t, simplifiedchinese.GBK, `<!doctype html><html><head><meta charset="gbk"></head><body>
+        <li class="it"><a class="nm" href="/s1">张三的博客</a></li></body></html>`)
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "text/html")
+        _, _ = w.Write(page)
+    }))
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{})
+    preset := rules.Preset{FriendsPage: &rules.FriendsPage{Item: ".it", Name: ".nm", Link: "a@href"}}
+    list, err := friends.ParseFriendsPage(context.Background(), cl, srv.URL, preset)
+    if err != nil { t.Fatalf("parse friends: %v", err) }
+    if len(list) != 1 || list[0].Name != "张三的博客" { t.Fatalf("friends=%+v", list) }
+}
+
+func TestCharset_FeedTranscoded(t *testing.T) {
+    rss := func(decl string) string {
+        return decl + `<rss version="2.0"><channel><title>博客</title>
+            <item><title>你好，世界</title><link>https://example.com/p1</link></item></channel></rss>`
+    }
+    cases := []struct {
+        name        string
+        contentType string
+        body        []byte
+    }{
+        {"xml_declaration", "application/rss+xml", encodeTo(t, simplifiedchinese.GBK, rss(`<?xml version="1.0" encoding="GBK"?>`))},
+        {"content_type_header", "application/rss+xml; charset=gb2312", encodeTo(t, simplifiedchinese.GBK, rss(""))},
+        {"header_overrides_declaration", "text/xml; charset=gbk", encodeTo(t, simplifiedchinese.GBK, rss(`<?xml version="1.0" encoding="utf-8"?>`))},
+        {"big5", "application/rss+xml; charset=big5", encodeTo(t, traditionalchinese.Big5, rss(""))},
+        {"plain_utf8", "application/rss+xml", []byte(rss(`<?xml version="1.0" encoding="utf-8"?>`))},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                w.Header().Set("Content-Type", c.contentType)
+                _, _ = w.Write(c.body)
+            }))
+            defer srv.Close()
+            cl, _ := fetch.New(fetch.Options{})
+            items, err := feeds.ParseFeed(context.Background(), cl, srv.URL, 0)
+            if err != nil { t.Fatalf("parse feed: %v", err) }
+            if len(items) != 1 || items[0].Title != "你好，世界" { t.Fatalf("items=%+v", items) }
+        })
+    }
+}
+
+func TestCharset_DetectCharset(t *testing.T) {
+    cases := []struct {
+        body, contentType, want string
+    }{
+        {`<?xml version="1.0" encoding="gb2312"?><rss/>`, "", "gbk"},
+        {`<html><head><meta http-equiv="Content-Type" content="text/html; charset=big5"></head></html>`, "text/html", "big5"},
+        {`<html><head><meta charset="gbk"></head></html>`, "text/html; charset=utf-8", "utf-8"},
+        {"\xef\xbb\xbf<rss/>", "text/xml; charset=gbk", "utf-8"},
+        {`<rss/>`, "", "utf-8"},
+        {"<html><head><meta charset=\"iso-8859-1\"></head><body>caf\xe9</body></html>", "text/html", "windows-1252"},
+        {"<html><head><meta http-equiv=\"Content-Type\" content=\"text/html; charset=windows-1252\"></head></html>", "", "windows-1252"},
+        {"<html><body>caf\xe9</body></html>", "text/html; charset=ISO-8859-1", "windows-1252"},
+        {"<html><body>caf\xe9</body></html>", "text/html", "utf-8"},
+    }
+    for _, c := range cases {
+        if got := fetch.DetectCharset([]byte(c.body), c.contentType); got != c.want {
+            t.Fatalf("DetectCharset(%q, %q)=%q want=%q", c.body, c.contentType, got, c.want)
+        }
+    }
+}
+
+func TestCharset_Latin1MetaDecoded(t *testing.T) {
+    body := []byte("<html><head><meta charset=\"iso-8859-1\"><title>Caf\xe9</title></head></html>")
+    out, name := fetch.ToUTF8(body, "text/html")
+    if name != "windows-1252" || !strings.Contains(string(out), "<title>Café</title>") { t.Fatalf("name=%s out=%q", name, out) }
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+    store "go-circle-of-friends/internal/store"
+)
+
+// conditionalFeedServer 返回带 ETag 的订阅，命中 If-None-Match 时返回 304。
+func conditionalFeedServer(t *testing.T, notModified *int32) *httptest.Server {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+        if r.Header.Get("If-None-Match") == `"v1"` {
+            atomic.AddInt32(notModified, 1)
+            w.WriteHeader(http.StatusNotModified)
+            return
+        }
+        w.Header().Set("ETag", `"v1"`)
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(rssSample))
+    })
+    srv := httptest.NewServer(mux)
+    t.Cleanup(srv.Close)
+    return srv
+}
+
+func TestConditional_NormalModeKeepsStoredPosts(t *testing.T) {
+    var hits int32
+    srv := conditionalFeedServer(t, &hits)
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "v.db"))
+    if err != nil { t.Fatalf("open: %v", err) }
+    defer st.Close()
+    ctx := context.Background()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, Validators: st})
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "v", Link: srv.URL, FeedSuffix: "/index.xml"}},
+        Concurrency:   config.Concurrency{Fetch: 1, Probe: 1},
+        FeedCacheTTL:  time.Hour,
+    }
+    for i := 0; i < 2; i++ {
+        if err := aggregate.New(cfg, st, cl, nil).Run(ctx); err != nil { t.Fatalf("run %d: %v", i, err) }
+    }
+    if n := atomic.LoadInt32(&hits); n != 1 { t.Fatalf("304 responses=%d want=1", n) }
+    ps, _ := st.ListPosts(ctx)
+    if len(ps) != 1 { t.Fatalf("posts after 304=%d want=1", len(ps)) }
+    fr, _ := st.ListFriends(ctx)
+    if len(fr) != 1 || fr[0].Error != "" { t.Fatalf("friend should stay healthy: %+v", fr) }
+
+    // Reset 需同时清除校验值，否则清空后的文章无法恢复
+    if err := st.Reset(ctx); err != nil { t.Fatalf("reset: %v", err) }
+    if _, ok, _ := st.LoadValidator(ctx, srv.URL+"/index.xml"); ok { t.Fatalf("validators should be cleared by reset") }
+}
+
+func TestConditional_SimpleModeRestoresPostsFromState(t *testing.T) {
+    var hits int32
+    srv := conditionalFeedServer(t, &hits)
+    path := filepath.Join(t.TempDir(), "state.json")
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "v", Link: srv.URL, FeedSuffix: "/index.xml"}},
+        SimpleMode:    true,
+        Concurrency:   config.Concurrency{Fetch: 1, Probe: 1},
+    }
+    for i := 0; i < 2; i++ {
+        // 每轮重新加载状态文件，模拟独立进程
+        state, err := aggregate.LoadSimpleState(path)
+        if err != nil { t.Fatalf("load state: %v", err) }
+        cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, Validators: state})
+        run := aggregate.New(cfg, nil, cl, nil).UseState(state)
+        if err := run.Run(context.Background()); err != nil { t.Fatalf("run %d: %v", i, err) }
+        if err := state.Save(); err != nil { t.Fatalf("save: %v", err) }
+        _, ps := run.BufferData()
+        if len(ps) != 1 || ps[0].Title != "a" { t.Fatalf("run %d posts: %+v", i, ps) }
+    }
+    if n := atomic.LoadInt32(&hits); n != 1 { t.Fatalf("304 responses=%d want=1", n) }
+}
+
+func TestConditional_NotModifiedKeepsRepaired(t *testing.T) {
+    var hits int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/index.xml" { http.NotFound(w, r); return }
+        if r.Header.Get("If-None-Match") == `"v1"` {
+            atomic.AddInt32(&hits, 1)
+            w.WriteHeader(http.StatusNotModified)
+            return
+        }
+        w.Header().Set("ETag", `"v1"`)
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(brokenFeed))
+    }))
+    defer srv.Close()
+    const want = "leading_whitespace,control_chars,html_entities,bare_ampersand"
+    ctx := context.Background()
+
+    // 正常模式：304 时保留库中的修复记录
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "v.db"))
+    if err != nil { t.Fatalf("open: %v", err) }
+    defer st.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, Validators: st})
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "v", Link: srv.URL, FeedSuffix: "/index.xml"}},
+        Concurrency:   config.Concurrency{Fetch: 1, Probe: 1},
+        FeedCacheTTL:  time.Hour,
+    }
+    for i := 0; i < 2; i++ {
+        if err := aggregate.New(cfg, st, cl, nil).Run(ctx); err != nil { t.Fatalf("run %d: %v", i, err) }
+        fr, _ := st.ListFriends(ctx)
+        if len(fr) != 1 || fr[0].Repaired != want { t.Fatalf("normal run %d friends=%+v", i, fr) }
+    }
+
+    // 极简模式：修复项随状态文件保留
+    path := filepath.Join(t.TempDir(), "state.json")
+    simple := *cfg
+    simple.SimpleMode = true
+    for i := 0; i < 2; i++ {
+        state, err := aggregate.LoadSimpleState(path)
+        if err != nil { t.Fatalf("load state: %v", err) }
+        cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, Validators: state})
+        run := aggregate.New(&simple, nil, cl, nil).UseState(state)
+        if err := run.Run(ctx); err != nil { t.Fatalf("run %d: %v", i, err) }
+        if err := state.Save(); err != nil { t.Fatalf("save: %v", err) }
+        fr, _ := run.BufferData()
+        if len(fr) != 1 || fr[0].Repaired != want { t.Fatalf("simple run %d friends=%+v", i, fr) }
+    }
+    if n := atomic.LoadInt32(&hits); n != 2 { t.Fatalf("304 responses=%d want=2", n) }
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+    "context"
+    "encoding/pem"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/rules"
+    store "go-circle-of-friends/internal/store"
+)
+
+// writeCA 将测试服务器的自签名证书写为 PEM 文件。
+func writeCA(t *testing.T, srv *httptest.Server) string {
+    t.Helper()
+    p := filepath.Join(t.TempDir(), "ca.pem")
+    b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+    if err := os.WriteFile(p, b, 0o644); err != nil { t.Fatalf("write ca: %v", err) }
+    return p
+}
+
+func TestDNS_HostsOverride(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write([]byte(r.Host))
+    }))
+    defer srv.Close()
+    port := srv.Listener.Addr().(*net.TCPAddr).Port
+    cl, err := fetch.New(fetch.Options{Timeout: 3 * time.Second, Hosts: map[string]string{"Blog.Example.TEST": "127.0.0.1"}})
+    if err != nil { t.Fatalf("new: %v", err) }
+    resp, err := cl.Get(context.Background(), "http://blog.example.test:"+strconv.Itoa(port)+"/")
+    if err != nil { t.Fatalf("get: %v", err) }
+    defer resp.Body.Close()
+    // Host 头保持原主机名，仅连接地址被覆盖
+    b, _ := io.ReadAll(resp.Body)
+    if !strings.HasPrefix(string(b), "blog.example.test:") { t.Fatalf("host=%q", b) }
+
+    if _, err := fetch.New(fetch.Options{Hosts: map[string]string{"a.test": "not-an-ip"}}); err == nil { t.Fatalf("expected invalid ip error") }
+}
+
+func TestTLS_PerHost(t *testing.T) {
+    srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write([]byte("ok"))
+    }))
+    defer srv.Close()
+    port := strconv.Itoa(srv.Listener.Addr().(*net.TCPAddr).Port)
+    ctx := context.Background()
+    // 测试证书签发给 example.com 与 127.0.0.1
+    hosts := map[string]string{"example.com": "127.0.0.1"}
+
+    // 默认使用系统根证书，自签名证书被拒绝
+    def, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, Retry: 0, Hosts: hosts})
+    if _, err := def.Get(ctx, "https://example.com:"+port+"/", fetch.NoRetry()); err == nil { t.Fatalf("expected verify error") }
+
+    // 指定 CA 文件后校验通过，其他主机仍按系统根证书校验
+    ca, err := fetch.New(fetch.Options{Timeout: 3 * time.Second, Hosts: hosts, HostProfiles: []fetch.HostProfile{{Host: "example.com", CAFile: writeCA(t, srv)}}})
+    if err != nil { t.Fatalf("new: %v", err) }
+    resp, err := ca.Get(ctx, "https://example.com:"+port+"/", fetch.NoRetry())
+    if err != nil { t.Fatalf("ca get: %v", err) }
+    resp.Body.Close()
+    if _, err := ca.Get(ctx, "https://127.0.0.1:"+port+"/", fetch.NoRetry()); err == nil { t.Fatalf("expected verify error for other host") }
+    // 记录证书过期时间
+    exp, ok := ca.CertExpiry("example.com")
+    if !ok || !exp.Equal(srv.Certificate().NotAfter) { t.Fatalf("cert expiry=%v ok=%v", exp, ok) }
+
+    // 显式跳过校验
+    skip, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, HostProfiles: []fetch.HostProfile{{Host: "127.0.0.1", InsecureSkipVerify: true}}})
+    resp, err = skip.Get(ctx, "https://127.0.0.1:"+port+"/", fetch.NoRetry())
+    if err != nil { t.Fatalf("skip get: %v", err) }
+    resp.Body.Close()
+
+    if _, err := fetch.New(fetch.Options{HostProfiles: []fetch.HostProfile{{Host: "a.test", CAFile: filepath.Join(t.TempDir(), "missing.pem")}}}); err == nil { t.Fatalf("expected ca file error") }
+}
+
+func TestTLS_FriendCertExpiry(t *testing.T) {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>t</title>
+        <item><title>a</title><link>http://ex/a</link></item></channel></rss>`))
+    })
+    srv := httptest.NewTLSServer(mux)
+    defer srv.Close()
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "t.db"))
+    if err != nil { t.Fatalf("open sqlite: %v", err) }
+    defer st.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, HostProfiles: []fetch.HostProfile{{Host: "127.0.0.1", CAFile: writeCA(t, srv)}}})
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "tls", Link: srv.URL, FeedSuffix: "/index.xml"}},
+        Concurrency: config.Concurrency{Fetch: 1},
+    }
+    if err := aggregate.New(cfg, st, cl, &rules.Rules{}).Run(context.Background()); err != nil { t.Fatalf("run: %v", err) }
+    fs, err := st.ListFriends(context.Background())
+    if err != nil { t.Fatalf("list friends: %v", err) }
+    if len(fs) != 1 || fs[0].CertExpiresAt == nil || !fs[0].CertExpiresAt.Equal(srv.Certificate().NotAfter) { t.Fatalf("friends=%#v", fs) }
+
+    // 再次写入未观察到证书时保留已有记录
+    f := fs[0]
+    f.CertExpiresAt = nil
+    if err := st.UpsertFriend(context.Background(), f); err != nil { t.Fatalf("upsert: %v", err) }
+    fs, _ = st.ListFriends(context.Background())
+    if fs[0].CertExpiresAt == nil { t.Fatalf("cert expiry lost") }
+}
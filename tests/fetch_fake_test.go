@@ -0,0 +1,55 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "testing"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/feeds"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/rules"
+)
+
+func TestFake_RunsPipelineWithoutNetwork(t *testing.T) {
+    fk := fetch.NewFake().
+        SetBody("https://me.example/links", "text/html", `<ul><li class="it"><a class="nm" href="https://a.example/">A</a></li></ul>`).
+        SetBody("https://a.example/atom.xml", "application/rss+xml", rssSample).
+        Set("https://down.example/", fetch.FakeResponse{Err: errors.New("connection refused")})
+    rl := &rules.Rules{Presets: map[string]rules.Preset{
+        "Default": {FriendsPage: &rules.FriendsPage{Item: ".it", Name: ".nm", Link: "a@href"}},
+    }}
+    cfg := &config.Config{
+        LinkSources:   []config.LinkSource{{Type: "page", URL: "https://me.example/links"}},
+        StaticFriends: []config.StaticFriend{{Name: "down", Link: "https://down.example/"}},
+        SimpleMode:    true,
+        Concurrency:   config.Concurrency{Fetch: 2, Probe: 2},
+    }
+    run := aggregate.New(cfg, nil, fk, rl)
+    if err := run.Run(context.Background()); err != nil { t.Fatalf("run: %v", err) }
+    fr, ps := run.BufferData()
+    byName := map[string]string{}
+    for _, f := range fr { byName[f.Name] = f.Error }
+    if len(fr) != 2 || byName["A"] != "" || byName["down"] == "" { t.Fatalf("friends=%+v", fr) }
+    if len(ps) != 1 || ps[0].FriendLink != "https://a.example/" { t.Fatalf("posts=%+v", ps) }
+}
+
+func TestFake_MatchesClientSemantics(t *testing.T) {
+    ctx := context.Background()
+    fk := fetch.NewFake().Set("https://b.example/feed", fetch.FakeResponse{
+        Header: http.Header{"Content-Type": {"application/rss+xml"}, "ETag": {`"v1"`}},
+        Body:   rssSample,
+    })
+    var se *fetch.StatusError
+    if _, err := fk.Get(ctx, "https://b.example/missing"); !errors.As(err, &se) || se.Code != http.StatusNotFound {
+        t.Fatalf("unregistered url err=%v want 404 StatusError", err)
+    }
+    items, err := feeds.ParseFeed(ctx, fk, "https://b.example/feed", 0)
+    if err != nil || len(items) != 1 { t.Fatalf("parse: items=%d err=%v", len(items), err) }
+    if _, err := feeds.ParseFeed(ctx, fk, "https://b.example/feed", 0); !errors.Is(err, feeds.ErrNotModified) {
+        t.Fatalf("second parse err=%v want ErrNotModified", err)
+    }
+    if got := fk.Requests(); len(got) != 3 { t.Fatalf("requests=%v", got) }
+}
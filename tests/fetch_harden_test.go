@@ -0,0 +1,103 @@
+package tests
+
+import (
+    "bytes"
+    "compress/gzip"
+    "context"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/feeds"
+    "go-circle-of-friends/internal/fetch"
+)
+
+func TestHarden_BodyLimits(t *testing.T) {
+    var bomb bytes.Buffer
+    zw := gzip.NewWriter(&bomb)
+    _, _ = zw.Write([]byte("<rss>" + strings.Repeat(" ", 8<<20) + "</rss>"))
+    _ = zw.Close()
+    mux := http.NewServeMux()
+    mux.HandleFunc("/sized", func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write(bytes.Repeat([]byte("a"), 2048))
+    })
+    mux.HandleFunc("/chunked", func(w http.ResponseWriter, r *http.Request) {
+        for i := 0; i < 4; i++ {
+            _, _ = w.Write(bytes.Repeat([]byte("a"), 512))
+            w.(http.Flusher).Flush()
+        }
+    })
+    mux.HandleFunc("/bomb.xml", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Encoding", "gzip")
+        w.Header().Set("Content-Type", "application/rss+xml")
+        w.(http.Flusher).Flush()
+        _, _ = w.Write(bomb.Bytes())
+    })
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, MaxPageBytes: 1024, MaxFeedBytes: 1 << 20})
+    ctx := context.Background()
+
+    // 已知 Content-Length 时直接拒绝
+    if _, err := cl.Get(ctx, srv.URL+"/sized"); !errors.Is(err, fetch.ErrBodyTooLarge) { t.Fatalf("sized err=%v", err) }
+    // 分块传输时读取到上限后报错，而不是静默截断
+    resp, err := cl.Get(ctx, srv.URL+"/chunked")
+    if err != nil { t.Fatalf("chunked get: %v", err) }
+    _, err = io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if !errors.Is(err, fetch.ErrBodyTooLarge) { t.Fatalf("chunked read err=%v", err) }
+    // 压缩炸弹：按解压后大小计数
+    if _, err := feeds.ParseFeed(ctx, cl, srv.URL+"/bomb.xml", 0); !errors.Is(err, fetch.ErrBodyTooLarge) { t.Fatalf("bomb err=%v", err) }
+}
+
+func TestHarden_Redirects(t *testing.T) {
+    var hits int32
+    mux := http.NewServeMux()
+    mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&hits, 1)
+        http.Redirect(w, r, "/b", http.StatusFound)
+    })
+    mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/a", http.StatusFound) })
+    mux.HandleFunc("/chain/", func(w http.ResponseWriter, r *http.Request) {
+        n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/chain/"))
+        http.Redirect(w, r, "/chain/"+strconv.Itoa(n+1), http.StatusFound)
+    })
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, Retry: 2, MaxRedirects: 3})
+    ctx := context.Background()
+    if _, err := cl.Get(ctx, srv.URL+"/a"); !errors.Is(err, fetch.ErrRedirectLoop) { t.Fatalf("loop err=%v", err) }
+    if n := atomic.LoadInt32(&hits); n != 1 { t.Fatalf("redirect loop should not be retried, hits=%d", n) }
+    if _, err := cl.Get(ctx, srv.URL+"/chain/0"); !errors.Is(err, fetch.ErrTooManyRedirects) { t.Fatalf("chain err=%v", err) }
+}
+
+func TestHarden_BlockPrivate(t *testing.T) {
+    var origin int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&origin, 1)
+    }))
+    defer srv.Close()
+    port := srv.URL[strings.LastIndex(srv.URL, ":"):]
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, Retry: 2, BlockPrivate: true})
+    ctx := context.Background()
+    for _, u := range []string{srv.URL, "http://localhost" + port, "http://[::1]" + port, "http://10.0.0.1/"} {
+        if _, err := cl.Get(ctx, u); !errors.Is(err, fetch.ErrPrivateAddress) { t.Fatalf("%s err=%v", u, err) }
+    }
+    if n := atomic.LoadInt32(&origin); n != 0 { t.Fatalf("private origin was contacted %d times", n) }
+
+    // 经代理的请求由代理负责解析，本地代理本身不受限制
+    proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write([]byte("via proxy"))
+    }))
+    defer proxy.Close()
+    pcl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, BlockPrivate: true, ProxyHTTP: proxy.URL})
+    resp, err := pcl.Get(ctx, "http://friend.example/")
+    if err != nil { t.Fatalf("proxied get: %v", err) }
+    resp.Body.Close()
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/fetch"
+)
+
+func TestLimit_PerHostConcurrencyCap(t *testing.T) {
+    var inflight, peak int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        n := atomic.AddInt32(&inflight, 1)
+        for {
+            p := atomic.LoadInt32(&peak)
+            if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) { break }
+        }
+        time.Sleep(50 * time.Millisecond)
+        atomic.AddInt32(&inflight, -1)
+        _, _ = w.Write([]byte("ok"))
+    }))
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, MaxPerHost: 2})
+    var wg sync.WaitGroup
+    for i := 0; i < 6; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            resp, err := cl.Get(context.Background(), srv.URL)
+            if err != nil { t.Errorf("get: %v", err); return }
+            resp.Body.Close()
+        }()
+    }
+    wg.Wait()
+    if p := atomic.LoadInt32(&peak); p > 2 { t.Fatalf("peak in-flight=%d want<=2", p) }
+}
+
+func TestLimit_HostDelayAndGlobalRPS(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write([]byte("ok"))
+    }))
+    defer srv.Close()
+    cases := []struct {
+        name string
+        opts fetch.Options
+        min  time.Duration
+    }{
+        {"host_delay", fetch.Options{HostDelay: 80 * time.Millisecond}, 160 * time.Millisecond},
+        {"rps", fetch.Options{RPS: 20}, 100 * time.Millisecond},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            c.opts.Timeout = 3 * time.Second
+            cl, _ := fetch.New(c.opts)
+            start := time.Now()
+            for i := 0; i < 3; i++ {
+                resp, err := cl.Get(context.Background(), srv.URL)
+                if err != nil { t.Fatalf("get: %v", err) }
+                resp.Body.Close()
+            }
+            if d := time.Since(start); d < c.min { t.Fatalf("3 requests took %v want>=%v", d, c.min) }
+        })
+    }
+}
+
+func TestLimit_WaitHonoursContext(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write([]byte("ok"))
+    }))
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, HostDelay: time.Hour})
+    resp, err := cl.Get(context.Background(), srv.URL)
+    if err != nil { t.Fatalf("first get: %v", err) }
+    resp.Body.Close()
+    ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+    defer cancel()
+    if _, err := cl.Get(ctx, srv.URL); err == nil { t.Fatalf("expected context error while waiting for host delay") }
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+)
+
+func TestProfile_PerHostHeadersAndCookies(t *testing.T) {
+    t.Setenv("COF_UA", "env-agent")
+    type seen struct{ ua, accept, lang, cookie string }
+    got := map[string]seen{}
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        got[r.URL.Path] = seen{r.UserAgent(), r.Header.Get("Accept"), r.Header.Get("Accept-Language"), r.Header.Get("Cookie")}
+    }))
+    defer srv.Close()
+    port := srv.URL[strings.LastIndex(srv.URL, ":"):]
+
+    cl, _ := fetch.New(fetch.Options{
+        Timeout:   2 * time.Second,
+        UserAgent: "global-agent",
+        Headers:   map[string]string{"Accept-Language": "zh-CN", "Accept": "*/*"},
+        HostProfiles: []fetch.HostProfile{{
+            Host:      "localhost",
+            UserAgent: "host-agent",
+            Headers:   map[string]string{"Accept": "text/html"},
+            Cookies:   map[string]string{"cf_clearance": "abc"},
+        }},
+    })
+    for _, u := range []string{"http://127.0.0.1" + port + "/plain", "http://localhost" + port + "/profiled"} {
+        resp, err := cl.Get(context.Background(), u)
+        if err != nil { t.Fatalf("get %s: %v", u, err) }
+        resp.Body.Close()
+    }
+    if s := got["/plain"]; s != (seen{"global-agent", "*/*", "zh-CN", ""}) { t.Fatalf("plain request=%+v", s) }
+    if s := got["/profiled"]; s != (seen{"host-agent", "text/html", "zh-CN", "cf_clearance=abc"}) { t.Fatalf("profiled request=%+v", s) }
+}
+
+func TestProfile_ConfigHTTPBlock(t *testing.T) {
+    f := filepath.Join(t.TempDir(), "c.yaml")
+    _ = os.WriteFile(f, []byte(`HTTP:
+  user_agent: my-agent
+  headers:
+    Accept-Language: zh-CN
+  hosts:
+    - host: example.com
+      cookies:
+        a: b
+`), 0644)
+    c, err := config.Load(f)
+    if err != nil { t.Fatalf("load: %v", err) }
+    if c.HTTP.UserAgent != "my-agent" || c.HTTP.Headers["Accept-Language"] != "zh-CN" { t.Fatalf("http=%+v", c.HTTP) }
+    if len(c.HTTP.Hosts) != 1 || c.HTTP.Hosts[0].Cookies["a"] != "b" { t.Fatalf("hosts=%+v", c.HTTP.Hosts) }
+
+    _ = os.WriteFile(f, []byte("HTTP:\n  hosts:\n    - user_agent: x\n"), 0644)
+    if _, err := config.Load(f); err == nil { t.Fatalf("expect error for host profile without host") }
+}
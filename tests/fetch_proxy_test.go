@@ -0,0 +1,133 @@
+package tests
+
+import (
+    "context"
+    "encoding/binary"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/fetch"
+)
+
+// startSocks5 启动仅支持用户名密码认证与 CONNECT 的最小 SOCKS5 服务，返回地址与连接计数。
+func startSocks5(t *testing.T, user, pass string) (string, *int32) {
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil { t.Fatalf("listen: %v", err) }
+    t.Cleanup(func() { ln.Close() })
+    var conns int32
+    go func() {
+        for {
+            c, err := ln.Accept()
+            if err != nil { return }
+            go func(c net.Conn) {
+                defer c.Close()
+                buf := make([]byte, 512)
+                // 问候：VER NMETHODS METHODS...，选择 0x02（用户名密码）
+                if _, err := io.ReadFull(c, buf[:2]); err != nil { return }
+                if _, err := io.ReadFull(c, buf[:buf[1]]); err != nil { return }
+                _, _ = c.Write([]byte{5, 2})
+                // 认证：VER ULEN UNAME PLEN PASSWD
+                if _, err := io.ReadFull(c, buf[:2]); err != nil { return }
+                u := make([]byte, buf[1])
+                _, _ = io.ReadFull(c, u)
+                _, _ = io.ReadFull(c, buf[:1])
+                p := make([]byte, buf[0])
+                _, _ = io.ReadFull(c, p)
+                if string(u) != user || string(p) != pass {
+                    _, _ = c.Write([]byte{1, 1})
+                    return
+                }
+                _, _ = c.Write([]byte{1, 0})
+                // 请求：VER CMD RSV ATYP DST.ADDR DST.PORT
+                if _, err := io.ReadFull(c, buf[:4]); err != nil { return }
+                var host string
+                switch buf[3] {
+                case 1:
+                    _, _ = io.ReadFull(c, buf[:4])
+                    host = net.IP(buf[:4]).String()
+                case 3:
+                    _, _ = io.ReadFull(c, buf[:1])
+                    n := int(buf[0])
+                    _, _ = io.ReadFull(c, buf[:n])
+                    host = string(buf[:n])
+                default:
+                    return
+                }
+                _, _ = io.ReadFull(c, buf[:2])
+                port := binary.BigEndian.Uint16(buf[:2])
+                up, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+                if err != nil { return }
+                defer up.Close()
+                atomic.AddInt32(&conns, 1)
+                _, _ = c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+                go func() { _, _ = io.Copy(up, c) }()
+                _, _ = io.Copy(c, up)
+            }(c)
+        }
+    }()
+    return ln.Addr().String(), &conns
+}
+
+func TestProxy_RoutingRules(t *testing.T) {
+    var origin int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&origin, 1)
+        _, _ = w.Write([]byte("ok"))
+    }))
+    defer srv.Close()
+    port := srv.URL[len("http://127.0.0.1"):]
+
+    // HTTP 代理：记录目标主机与认证头
+    var proxied int32
+    var gotAuth atomic.Value
+    proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&proxied, 1)
+        gotAuth.Store(r.Header.Get("Proxy-Authorization"))
+        _, _ = w.Write([]byte("via proxy"))
+    }))
+    defer proxy.Close()
+    socksAddr, socksConns := startSocks5(t, "u", "p")
+
+    cl, err := fetch.New(fetch.Options{
+        Timeout:   3 * time.Second,
+        ProxyHTTP: "http://alice:secret@" + proxy.Listener.Addr().String(),
+        ProxyRules: []fetch.ProxyRule{
+            {Suffix: "localhost", Proxy: "socks5://u:p@" + socksAddr},
+            {Regex: `^127\.0\.0\.1$`, Proxy: fetch.ProxyDirect},
+        },
+    })
+    if err != nil { t.Fatalf("new: %v", err) }
+    get := func(u string) {
+        resp, err := cl.Get(context.Background(), u)
+        if err != nil { t.Fatalf("get %s: %v", u, err) }
+        resp.Body.Close()
+    }
+
+    get("http://127.0.0.1" + port + "/direct")
+    if atomic.LoadInt32(&origin) != 1 || atomic.LoadInt32(&proxied) != 0 { t.Fatalf("regex rule should go direct") }
+
+    get("http://localhost" + port + "/socks")
+    if atomic.LoadInt32(socksConns) != 1 || atomic.LoadInt32(&origin) != 2 { t.Fatalf("suffix rule should go through socks5") }
+
+    get("http://unmatched.example" + port + "/default")
+    if atomic.LoadInt32(&proxied) != 1 { t.Fatalf("unmatched host should use default http proxy") }
+    if a, _ := gotAuth.Load().(string); a != "Basic YWxpY2U6c2VjcmV0" { t.Fatalf("proxy auth=%q", a) }
+}
+
+func TestProxy_InvalidConfig(t *testing.T) {
+    bad := []fetch.Options{
+        {ProxyHTTP: "ftp://127.0.0.1:21"},
+        {ProxyRules: []fetch.ProxyRule{{Proxy: "direct"}}},
+        {ProxyRules: []fetch.ProxyRule{{Regex: "(", Proxy: "direct"}}},
+        {ProxyRules: []fetch.ProxyRule{{Suffix: "a.com", Proxy: "socks5://"}}},
+    }
+    for i, o := range bad {
+        if _, err := fetch.New(o); err == nil { t.Fatalf("case %d: expected error", i) }
+    }
+}
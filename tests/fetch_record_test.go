@@ -0,0 +1,102 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "path/filepath"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+)
+
+func TestRecordReplay_RunnerIsDeterministicOffline(t *testing.T) {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(rssSample))
+    })
+    srv := httptest.NewServer(mux)
+    dir := t.TempDir()
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "r", Link: srv.URL, FeedSuffix: "/index.xml"}},
+        SimpleMode:    true,
+        Concurrency:   config.Concurrency{Fetch: 1, Probe: 1},
+    }
+
+    rec, err := fetch.New(fetch.Options{Timeout: 3 * time.Second, RecordDir: dir})
+    if err != nil { t.Fatalf("record client: %v", err) }
+    run := aggregate.New(cfg, nil, rec, nil)
+    if err := run.Run(context.Background()); err != nil { t.Fatalf("record run: %v", err) }
+    _, want := run.BufferData()
+    if len(want) != 1 { t.Fatalf("recorded posts=%d want=1", len(want)) }
+
+    // 关闭服务端后只能依赖录制内容
+    srv.Close()
+    rep, err := fetch.New(fetch.Options{Timeout: 3 * time.Second, ReplayDir: dir})
+    if err != nil { t.Fatalf("replay client: %v", err) }
+    run = aggregate.New(cfg, nil, rep, nil)
+    if err := run.Run(context.Background()); err != nil { t.Fatalf("replay run: %v", err) }
+    fr, got := run.BufferData()
+    if len(fr) != 1 || fr[0].Error != "" { t.Fatalf("friend should be healthy on replay: %+v", fr) }
+    if len(got) != 1 || got[0].Title != want[0].Title || got[0].Link != want[0].Link {
+        t.Fatalf("replayed posts=%+v want=%+v", got, want)
+    }
+
+    if _, err := rep.Get(context.Background(), srv.URL+"/missing"); !errors.Is(err, fetch.ErrNotRecorded) {
+        t.Fatalf("missing recording err=%v want ErrNotRecorded", err)
+    }
+}
+
+func TestRecordReplay_MutuallyExclusive(t *testing.T) {
+    if _, err := fetch.New(fetch.Options{RecordDir: t.TempDir(), ReplayDir: t.TempDir()}); err == nil {
+        t.Fatalf("expected error when both record and replay are set")
+    }
+}
+
+func TestRecordReplay_RerecordKeepsFullResponses(t *testing.T) {
+    var hits int32
+    srv := conditionalFeedServer(t, &hits)
+    dir := t.TempDir()
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "v", Link: srv.URL, FeedSuffix: "/index.xml"}},
+        SimpleMode:    true,
+        Concurrency:   config.Concurrency{Fetch: 1, Probe: 1},
+    }
+    // 两次录制共用校验值，第二次得到 304
+    state, err := aggregate.LoadSimpleState(filepath.Join(t.TempDir(), "state.json"))
+    if err != nil { t.Fatalf("load state: %v", err) }
+    for i := 0; i < 2; i++ {
+        rec, err := fetch.New(fetch.Options{Timeout: 3 * time.Second, RecordDir: dir, Validators: state})
+        if err != nil { t.Fatalf("record client: %v", err) }
+        if err := aggregate.New(cfg, nil, rec, nil).UseState(state).Run(context.Background()); err != nil { t.Fatalf("record run %d: %v", i, err) }
+    }
+    if n := atomic.LoadInt32(&hits); n != 1 { t.Fatalf("304 responses=%d want=1", n) }
+
+    srv.Close()
+    rep, err := fetch.New(fetch.Options{Timeout: 3 * time.Second, ReplayDir: dir})
+    if err != nil { t.Fatalf("replay client: %v", err) }
+    run := aggregate.New(cfg, nil, rep, nil)
+    if err := run.Run(context.Background()); err != nil { t.Fatalf("replay run: %v", err) }
+    if _, ps := run.BufferData(); len(ps) != 1 { t.Fatalf("replayed posts=%d want=1 (304 overwrote recording?)", len(ps)) }
+}
+
+func TestRecordReplay_RecordingRespectsBodyLimit(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        // 分块传输，不带 Content-Length
+        for i := 0; i < 64; i++ {
+            _, _ = w.Write([]byte(strings.Repeat("x", 1024)))
+            w.(http.Flusher).Flush()
+        }
+    }))
+    defer srv.Close()
+    cl, err := fetch.New(fetch.Options{Timeout: 3 * time.Second, RecordDir: t.TempDir(), MaxPageBytes: 4096, MaxFeedBytes: 8192})
+    if err != nil { t.Fatalf("record client: %v", err) }
+    if _, err := cl.Get(context.Background(), srv.URL+"/big"); !errors.Is(err, fetch.ErrBodyTooLarge) { t.Fatalf("err=%v want ErrBodyTooLarge", err) }
+}
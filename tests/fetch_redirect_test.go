@@ -0,0 +1,51 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/fetch"
+)
+
+func TestRedirects_ChainAndMoved(t *testing.T) {
+    mux := http.NewServeMux()
+    mux.Handle("/a", http.RedirectHandler("/b", http.StatusMovedPermanently))
+    mux.Handle("/b", http.RedirectHandler("/c", http.StatusPermanentRedirect))
+    mux.Handle("/tmp", http.RedirectHandler("/c", http.StatusFound))
+    mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("ok")) })
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second})
+    ctx := context.Background()
+
+    resp, err := cl.Get(ctx, srv.URL+"/a")
+    if err != nil { t.Fatalf("get: %v", err) }
+    resp.Body.Close()
+    hops := fetch.Redirects(resp)
+    if len(hops) != 2 || hops[0].URL != srv.URL+"/a" || hops[0].Status != 301 || hops[1].URL != srv.URL+"/b" || hops[1].Status != 308 { t.Fatalf("hops=%+v", hops) }
+    if fetch.FinalURL(resp) != srv.URL+"/c" { t.Fatalf("final=%s", fetch.FinalURL(resp)) }
+    if to, ok := fetch.MovedTo(resp); !ok || to != srv.URL+"/c" { t.Fatalf("moved=%s ok=%v", to, ok) }
+
+    // 临时重定向不视为迁移
+    resp, err = cl.Get(ctx, srv.URL+"/tmp")
+    if err != nil { t.Fatalf("get tmp: %v", err) }
+    resp.Body.Close()
+    if _, ok := fetch.MovedTo(resp); ok { t.Fatalf("302 treated as moved") }
+    // 未重定向
+    resp, _ = cl.Get(ctx, srv.URL+"/c")
+    resp.Body.Close()
+    if len(fetch.Redirects(resp)) != 0 { t.Fatalf("unexpected hops") }
+}
+
+func TestRedirects_Fake(t *testing.T) {
+    fk := fetch.NewFake().
+        Set("http://old.test/feed", fetch.FakeResponse{Status: 301, Header: http.Header{"Location": {"https://new.test/feed"}}}).
+        SetBody("https://new.test/feed", "application/rss+xml", "<rss/>")
+    resp, err := fk.Get(context.Background(), "http://old.test/feed")
+    if err != nil { t.Fatalf("get: %v", err) }
+    if to, ok := fetch.MovedTo(resp); !ok || to != "https://new.test/feed" { t.Fatalf("moved=%s ok=%v", to, ok) }
+    if got := fk.Requests(); len(got) != 2 { t.Fatalf("requests=%v", got) }
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+)
+
+const robotsSample = `# comment
+User-agent: *
+Disallow: /private
+Allow: /private/ok$
+Disallow: /*.php
+
+User-agent: cof-bot
+Disallow: /
+`
+
+func TestRobots_RulesAndCaching(t *testing.T) {
+    var robotsHits, pageHits int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/robots.txt" {
+            atomic.AddInt32(&robotsHits, 1)
+            _, _ = w.Write([]byte(robotsSample))
+            return
+        }
+        atomic.AddInt32(&pageHits, 1)
+    }))
+    defer srv.Close()
+    ctx := context.Background()
+
+    cl, _ := fetch.New(fetch.Options{Timeout: 2 * time.Second, UserAgent: "Mozilla/5.0 generic", Robots: true})
+    cases := []struct {
+        path    string
+        allowed bool
+    }{
+        {"/public", true},
+        {"/private", false},
+        {"/private/x", false},
+        {"/private/ok", true},
+        {"/private/ok/more", false},
+        {"/rss.php", false},
+        {"/?feed=rss2", true},
+    }
+    for _, c := range cases {
+        resp, err := cl.Get(ctx, srv.URL+c.path)
+        if c.allowed {
+            if err != nil { t.Fatalf("%s should be allowed: %v", c.path, err) }
+            resp.Body.Close()
+        } else if !errors.Is(err, fetch.ErrDisallowed) {
+            t.Fatalf("%s err=%v want ErrDisallowed", c.path, err)
+        }
+    }
+    if n := atomic.LoadInt32(&robotsHits); n != 1 { t.Fatalf("robots.txt fetched %d times, want 1", n) }
+    if n := atomic.LoadInt32(&pageHits); n != 3 { t.Fatalf("page hits=%d want 3", n) }
+
+    // 具名 agent 组优先于 *
+    bot, _ := fetch.New(fetch.Options{Timeout: 2 * time.Second, UserAgent: "Mozilla/5.0 (compatible; cof-bot/1.0)", Robots: true})
+    if _, err := bot.Get(ctx, srv.URL+"/public"); !errors.Is(err, fetch.ErrDisallowed) { t.Fatalf("cof-bot err=%v", err) }
+
+    // 未开启时不获取 robots.txt
+    plain, _ := fetch.New(fetch.Options{Timeout: 2 * time.Second})
+    resp, err := plain.Get(ctx, srv.URL+"/private")
+    if err != nil { t.Fatalf("robots disabled: %v", err) }
+    resp.Body.Close()
+    if n := atomic.LoadInt32(&robotsHits); n != 2 { t.Fatalf("robots hits=%d want 2", n) }
+}
+
+func TestRobots_RunnerRecordsBlockedFriend(t *testing.T) {
+    var other int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/robots.txt" {
+            _, _ = w.Write([]byte("User-agent: *\nDisallow: /\n"))
+            return
+        }
+        atomic.AddInt32(&other, 1)
+        w.Header().Set("Content-Type", "application/rss+xml")
+        _, _ = w.Write([]byte(rssSample))
+    }))
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{Timeout: 2 * time.Second, Robots: true})
+    cfg := &config.Config{
+        StaticFriends: []config.StaticFriend{{Name: "r", Link: srv.URL, FeedSuffix: "/index.xml"}},
+        SimpleMode:    true,
+        Concurrency:   config.Concurrency{Fetch: 1, Probe: 2},
+    }
+    run := aggregate.New(cfg, nil, cl, nil)
+    if err := run.Run(context.Background()); err != nil { t.Fatalf("run: %v", err) }
+    fr, ps := run.BufferData()
+    if len(fr) != 1 || fr[0].Error != "blocked by robots.txt" { t.Fatalf("friends=%+v", fr) }
+    if len(ps) != 0 { t.Fatalf("posts=%d want 0", len(ps)) }
+    if n := atomic.LoadInt32(&other); n != 0 { t.Fatalf("disallowed site was fetched %d times", n) }
+}
+
+func TestRobots_RedirectHopsChecked(t *testing.T) {
+    var privateHits int32
+    target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/robots.txt":
+            // robots.txt 自身在同主机内重定向也能正常获取
+            http.Redirect(w, r, "/robots-real.txt", http.StatusMovedPermanently)
+        case "/robots-real.txt":
+            _, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+        case "/go":
+            http.Redirect(w, r, "/private/page", http.StatusFound)
+        default:
+            if r.URL.Path == "/private/page" { atomic.AddInt32(&privateHits, 1) }
+        }
+    }))
+    defer target.Close()
+    // 另一主机允许全部抓取，但重定向到 target 的禁止路径
+    other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path == "/robots.txt" { http.NotFound(w, r); return }
+        http.Redirect(w, r, target.URL+"/private/page", http.StatusMovedPermanently)
+    }))
+    defer other.Close()
+
+    cl, _ := fetch.New(fetch.Options{Timeout: 2 * time.Second, Robots: true})
+    ctx := context.Background()
+    for _, u := range []string{target.URL + "/go", other.URL + "/feed"} {
+        if _, err := cl.Get(ctx, u); !errors.Is(err, fetch.ErrDisallowed) { t.Fatalf("%s err=%v want ErrDisallowed", u, err) }
+    }
+    if n := atomic.LoadInt32(&privateHits); n != 0 { t.Fatalf("disallowed path fetched %d times", n) }
+}
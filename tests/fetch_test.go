@@ -57,6 +57,65 @@ func TestFetch_RetryOnStatus(t *testing.T) {
     }
 }
 
+func TestFetch_RetryClassification(t *testing.T) {
+    cases := []struct {
+        name  string
+        code  int
+        opts  []fetch.CallOption
+        calls int32
+    }{
+        {"not_found_is_final", http.StatusNotFound, nil, 1},
+        {"gone_is_final", http.StatusGone, nil, 1},
+        {"server_error_retried", http.StatusBadGateway, nil, 3},
+        {"no_retry_option", http.StatusBadGateway, []fetch.CallOption{fetch.NoRetry()}, 1},
+        {"with_retry_option", http.StatusServiceUnavailable, []fetch.CallOption{fetch.WithRetry(1)}, 2},
+    }
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            var calls int32
+            srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                atomic.AddInt32(&calls, 1)
+                w.WriteHeader(c.code)
+            }))
+            defer srv.Close()
+            cl, _ := fetch.New(fetch.Options{Retry: 2, Timeout: 2 * time.Second})
+            _, err := cl.Get(context.Background(), srv.URL, c.opts...)
+            var se *fetch.StatusError
+            if !errors.As(err, &se) || se.Code != c.code { t.Fatalf("err=%v want status %d", err, c.code) }
+            if n := atomic.LoadInt32(&calls); n != c.calls { t.Fatalf("calls=%d want=%d", n, c.calls) }
+        })
+    }
+}
+
+func TestFetch_RetryAfterHonoured(t *testing.T) {
+    var calls int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&calls, 1) == 1 {
+            w.Header().Set("Retry-After", "1")
+            w.WriteHeader(http.StatusTooManyRequests)
+            return
+        }
+        _, _ = w.Write([]byte("ok"))
+    }))
+    defer srv.Close()
+    cl, _ := fetch.New(fetch.Options{Retry: 1, Timeout: 2 * time.Second})
+    start := time.Now()
+    resp, err := cl.Get(context.Background(), srv.URL)
+    if err != nil { t.Fatalf("get: %v", err) }
+    _ = resp.Body.Close()
+    if d := time.Since(start); d < time.Second { t.Fatalf("retried after %v, want >= Retry-After 1s", d) }
+
+    // Retry-After 过长时直接放弃
+    atomic.StoreInt32(&calls, 0)
+    srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&calls, 1)
+        w.Header().Set("Retry-After", "3600")
+        w.WriteHeader(http.StatusTooManyRequests)
+    })
+    if _, err := cl.Get(context.Background(), srv.URL); err == nil { t.Fatalf("expected 429 error") }
+    if n := atomic.LoadInt32(&calls); n != 1 { t.Fatalf("calls=%d want=1 when Retry-After is too long", n) }
+}
+
 func TestFetch_Timeout(t *testing.T) {
     srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         time.Sleep(300 * time.Millisecond)
@@ -0,0 +1,89 @@
+package tests
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/sources"
+)
+
+// Hexo/Butterfly 的 source/_data/link.yml
+const butterflyLinkYAML = `
+- class_name: 友情链接
+  class_desc: 那些人，那些事
+  link_list:
+    - name: Hexo
+      link: https://hexo.io/zh-tw/
+      avatar: https://d33wubrfki0l68.cloudfront.net/6657ba50e702d84afb32fe846bed54fba1a77add/827ae/logo.svg
+      descr: 快速、简单且强大的网誌框架
+- class_name: 网站
+  link_list:
+    - name: Youtube
+      link: https://www.youtube.com/
+      avatar: https://i.loli.net/2020/05/14/9ZkGg8v3azHJfM1.png
+`
+
+// Hugo 主题常见的 data/friends.yaml
+const hugoFriendsYAML = `
+- title: Alice
+  url: https://alice.example
+  image: /img/alice.png
+- title: Bob
+  website: https://bob.example
+  rss: https://bob.example/index.xml
+- title: 1024
+  url: https://1024.example
+`
+
+func TestFriendsData_LocalYAML(t *testing.T) {
+    dir := t.TempDir()
+    link := filepath.Join(dir, "link.yml")
+    hugo := filepath.Join(dir, "friends.yaml")
+    if err := os.WriteFile(link, []byte(butterflyLinkYAML), 0o644); err != nil { t.Fatalf("write: %v", err) }
+    if err := os.WriteFile(hugo, []byte(hugoFriendsYAML), 0o644); err != nil { t.Fatalf("write: %v", err) }
+    ctx := context.Background()
+    cl := fetch.NewFake()
+
+    list, err := sourceFriends(ctx, cl, config.LinkSource{Type: "yaml", Path: link})
+    if err != nil { t.Fatalf("butterfly yaml: %v", err) }
+    if len(list) != 2 || list[0].Name != "Hexo" || list[1].Link != "https://www.youtube.com/" { t.Fatalf("butterfly=%+v", list) }
+
+    list, err = sourceFriends(ctx, cl, config.LinkSource{Type: "yaml", Path: hugo})
+    if err != nil { t.Fatalf("hugo yaml: %v", err) }
+    // 数字名称按字符串读取；本地文件的相对头像保持原样
+    if len(list) != 3 || list[0].Avatar != "/img/alice.png" || list[1].Link != "https://bob.example" || list[1].Feed != "https://bob.example/index.xml" || list[2].Name != "1024" { t.Fatalf("hugo=%+v", list) }
+    if len(cl.Requests()) != 0 { t.Fatalf("local source should not fetch: %v", cl.Requests()) }
+
+    if _, err := sourceFriends(ctx, cl, config.LinkSource{Type: "yaml", Path: filepath.Join(dir, "missing.yml")}); err == nil { t.Fatalf("expected missing file error") }
+}
+
+func TestFriendsData_RemoteYAMLAndLocalJSON(t *testing.T) {
+    cl := fetch.NewFake().SetBody("https://me.example/link.yml", "text/yaml", butterflyLinkYAML)
+    list, err := sourceFriends(context.Background(), cl, config.LinkSource{Type: "yaml", URL: "https://me.example/link.yml", Theme: "butterfly"})
+    if err != nil || len(list) != 2 { t.Fatalf("remote yaml: %v %+v", err, list) }
+
+    p := filepath.Join(t.TempDir(), "friend.json")
+    if err := os.WriteFile(p, []byte(`{"friends": [["A", "https://a.example", ""]]}`), 0o644); err != nil { t.Fatalf("write: %v", err) }
+    list, err = sourceFriends(context.Background(), cl, config.LinkSource{Type: "json", Path: p})
+    if err != nil || len(list) != 1 || list[0].Link != "https://a.example" { t.Fatalf("local json: %v %+v", err, list) }
+}
+
+func TestFriendsData_ValidateSources(t *testing.T) {
+    bad := []config.LinkSource{
+        {Type: "yaml"},
+        {Type: "yaml", URL: "https://x", Path: "x.yml"},
+        {Type: "page", Path: "x.html"},
+        {Type: "json", URL: "https://x", Theme: "nope"},
+        {Type: "toml", URL: "https://x"},
+    }
+    for _, src := range bad {
+        if err := sources.Validate([]config.LinkSource{src}); err == nil { t.Fatalf("expected error for %+v", src) }
+    }
+    c := config.Config{LinkSources: []config.LinkSource{{URL: "https://x/links"}, {Type: "yaml", Path: "link.yml"}}}
+    if err := c.Validate(); err != nil || c.LinkSources[0].Type != "page" { t.Fatalf("validate: %v %+v", err, c.LinkSources) }
+    if err := sources.Validate(c.LinkSources); err != nil { t.Fatalf("sources validate: %v", err) }
+}
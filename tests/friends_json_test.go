@@ -0,0 +1,88 @@
+package tests
+
+import (
+    "context"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/rules"
+    store "go-circle-of-friends/internal/store"
+)
+
+func TestFriendsJSON_BuiltinFormats(t *testing.T) {
+    fk := fetch.NewFake().
+        SetBody("https://a.test/friend.json", "application/json", `{"friends": [["A", "https://a1.test", "https://a1.test/a.png"], ["B", "/b", ""]]}`).
+        SetBody("https://b.test/flink.json", "application/json", `[
+            {"class_name": "朋友", "link_list": [{"name": "C", "link": "https://c.test", "avatar": "https://c.test/c.png"}]},
+            {"class_name": "大佬", "link_list": [{"name": "D", "link": "https://d.test", "feed": "https://d.test/rss.xml"}]}
+        ]`)
+    ctx := context.Background()
+
+    for _, theme := range []string{"fcircle", ""} {
+        list, err := sourceFriends(ctx, fk, config.LinkSource{Type: "json", URL: "https://a.test/friend.json", Theme: theme})
+        if err != nil { t.Fatalf("fcircle(%q): %v", theme, err) }
+        if len(list) != 2 || list[0].Name != "A" || list[0].Avatar != "https://a1.test/a.png" || list[1].Link != "https://a.test/b" { t.Fatalf("fcircle(%q)=%+v", theme, list) }
+    }
+    for _, theme := range []string{"butterfly", ""} {
+        list, err := sourceFriends(ctx, fk, config.LinkSource{Type: "json", URL: "https://b.test/flink.json", Theme: theme})
+        if err != nil { t.Fatalf("butterfly(%q): %v", theme, err) }
+        if len(list) != 2 || list[0].Name != "C" || list[1].Feed != "https://d.test/rss.xml" { t.Fatalf("butterfly(%q)=%+v", theme, list) }
+    }
+    if _, err := sourceFriends(ctx, fk, config.LinkSource{Type: "json", URL: "https://a.test/friend.json", Theme: "nope"}); err == nil { t.Fatalf("expected unknown format error") }
+}
+
+func TestFriendsJSON_CustomMapping(t *testing.T) {
+    fk := fetch.NewFake().SetBody("https://x.test/api/links", "application/json", `{"data": {"groups": {
+        "b": {"items": [{"title": "Two", "site": {"url": "https://two.test"}, "icon": "", "logo": "https://two.test/l.png"}]},
+        "a": {"items": [{"title": "One", "site": {"url": "https://one.test"}, "rss": "/one.xml"}]}
+    }}}`)
+    src := config.LinkSource{Type: "json", URL: "https://x.test/api/links", Mapping: config.JSONMapping{
+        Items:  `$.data.groups[*]["items"][*]`,
+        Name:   "title",
+        Link:   "$.site.url",
+        Avatar: "icon||logo",
+        Feed:   "rss",
+    }}
+    list, err := sourceFriends(context.Background(), fk, src)
+    if err != nil { t.Fatalf("custom: %v", err) }
+    // 对象按键名排序展开
+    if len(list) != 2 || list[0].Name != "One" || list[0].Feed != "https://x.test/one.xml" || list[1].Avatar != "https://two.test/l.png" || list[1].Feed != "" { t.Fatalf("custom=%+v", list) }
+
+    src.Mapping.Items = "$.data[oops"
+    if _, err := sourceFriends(context.Background(), fk, src); err == nil || !strings.Contains(err.Error(), "json path") { t.Fatalf("expected path error, got %v", err) }
+}
+
+func TestFriendsJSON_RunnerUsesExplicitFeed(t *testing.T) {
+    fk := fetch.NewFake().
+        SetBody("https://list.test/friend.json", "application/json", `{"friends": [["E", "https://e.test", ""]]}`).
+        SetBody("https://e.test/custom/feed", "application/rss+xml", `<?xml version="1.0"?><rss version="2.0"><channel><title>e</title>
+            <item><title>p</title><link>https://e.test/p</link></item></channel></rss>`)
+    st, err := store.OpenSQLite(filepath.Join(t.TempDir(), "t.db"))
+    if err != nil { t.Fatalf("open sqlite: %v", err) }
+    defer st.Close()
+    cfg := &config.Config{
+        LinkSources: []config.LinkSource{{Type: "json", URL: "https://list.test/friend.json", Mapping: config.JSONMapping{Feed: "[3]"}}},
+        StaticFriends: []config.StaticFriend{{Name: "F", Link: "https://f.test", Feed: "https://e.test/custom/feed"}},
+        Concurrency: config.Concurrency{Fetch: 1},
+    }
+    if err := aggregate.New(cfg, st, fk, &rules.Rules{}).Run(context.Background()); err != nil { t.Fatalf("run: %v", err) }
+    fs, _ := st.ListFriends(context.Background())
+    if len(fs) != 2 { t.Fatalf("friends=%+v", fs) }
+    for _, f := range fs {
+        switch f.Name {
+        case "E":
+            // 未给出订阅：走发现流程，最终失败
+            if f.Error == "" { t.Fatalf("E should fail discovery: %+v", f) }
+        case "F":
+            if f.Error != "" || f.Feed != "https://e.test/custom/feed" { t.Fatalf("F=%+v", f) }
+        }
+    }
+    // 显式订阅不探测候选路径
+    for _, u := range fk.Requests() {
+        if strings.HasPrefix(u, "https://f.test") { t.Fatalf("unexpected discovery request %s", u) }
+    }
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/export"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/model"
+    store "go-circle-of-friends/internal/store"
+)
+
+const sampleOPML = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>subs</title></head>
+  <body>
+    <outline text="博客">
+      <outline text="Alice" type="rss" xmlUrl="https://alice.example/atom.xml" htmlUrl="https://alice.example/"/>
+      <outline title="Bob" type="rss" xmlUrl="https://bob.example/feed/"/>
+    </outline>
+    <outline text="Carol" type="rss" xmlUrl="/carol.xml" htmlUrl="/carol/"/>
+  </body>
+</opml>`
+
+func TestOPML_Import(t *testing.T) {
+    p := filepath.Join(t.TempDir(), "subs.opml")
+    if err := os.WriteFile(p, []byte(sampleOPML), 0o644); err != nil { t.Fatalf("write: %v", err) }
+    cl := fetch.NewFake().SetBody("https://me.example/subs.opml", "text/x-opml", sampleOPML)
+    ctx := context.Background()
+
+    local, err := sourceFriends(ctx, cl, config.LinkSource{Type: "opml", Path: p})
+    if err != nil { t.Fatalf("local opml: %v", err) }
+    // 分类节点展开；缺少 htmlUrl 时取订阅站点根地址
+    if len(local) != 3 || local[0].Name != "Alice" || local[0].Feed != "https://alice.example/atom.xml" || local[1].Name != "Bob" || local[1].Link != "https://bob.example/" { t.Fatalf("local=%+v", local) }
+
+    remote, err := sourceFriends(ctx, cl, config.LinkSource{Type: "opml", URL: "https://me.example/subs.opml"})
+    if err != nil { t.Fatalf("remote opml: %v", err) }
+    if len(remote) != 3 || remote[2].Feed != "https://me.example/carol.xml" || remote[2].Link != "https://me.example/carol/" { t.Fatalf("remote=%+v", remote) }
+}
+
+func TestOPML_ExportRoundTrip(t *testing.T) {
+    dir := t.TempDir()
+    st, err := store.OpenSQLite(filepath.Join(dir, "t.db"))
+    if err != nil { t.Fatalf("open sqlite: %v", err) }
+    defer st.Close()
+    ctx := context.Background()
+    for _, f := range []model.Friend{
+        {Name: "Alive & Well", Link: "https://a.example/", Feed: "https://a.example/atom.xml"},
+        {Name: "Broken", Link: "https://b.example/", Feed: "https://b.example/rss", Error: "boom"},
+        {Name: "NoFeed", Link: "https://c.example/"},
+    } {
+        if err := st.UpsertFriend(ctx, f); err != nil { t.Fatalf("upsert: %v", err) }
+    }
+    out := filepath.Join(dir, "friends.opml")
+    if err := export.ToOPML(ctx, st, out); err != nil { t.Fatalf("export: %v", err) }
+    b, _ := os.ReadFile(out)
+    if !strings.Contains(string(b), `text="Alive &amp; Well"`) || strings.Contains(string(b), "Broken") || strings.Contains(string(b), "NoFeed") { t.Fatalf("opml=%s", b) }
+
+    // 导出结果可再作为来源导入
+    list, err := sourceFriends(ctx, fetch.NewFake(), config.LinkSource{Type: "opml", Path: out})
+    if err != nil { t.Fatalf("reimport: %v", err) }
+    if len(list) != 1 || list[0].Name != "Alive & Well" || list[0].Link != "https://a.example/" || list[0].Feed != "https://a.example/atom.xml" { t.Fatalf("reimport=%+v", list) }
+}
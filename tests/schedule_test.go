@@ -0,0 +1,57 @@
+package tests
+
+import (
+    "context"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "go-circle-of-friends/internal/schedule"
+)
+
+func TestSchedule_CronNext(t *testing.T) {
+    c, err := schedule.ParseCron("*/15 9-17 * * 1-5")
+    if err != nil { t.Fatalf("parse: %v", err) }
+    // 2024-01-05 是周五
+    from := time.Date(2024, 1, 5, 17, 50, 0, 0, time.UTC)
+    got := c.Next(from)
+    want := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+    if !got.Equal(want) { t.Fatalf("next=%s want=%s", got, want) }
+
+    d, err := schedule.ParseCron("@daily")
+    if err != nil { t.Fatalf("parse alias: %v", err) }
+    if got := d.Next(from); !got.Equal(time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)) { t.Fatalf("daily next=%s", got) }
+
+    for _, bad := range []string{"* * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *"} {
+        if _, err := schedule.ParseCron(bad); err == nil { t.Fatalf("expect error for %q", bad) }
+    }
+}
+
+func TestSchedule_RunSkipsOverlapAndStopsOnCancel(t *testing.T) {
+    ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+    defer cancel()
+    var active, maxActive, runs int32
+    var cancelled atomic.Bool
+    done := make(chan struct{})
+    go func() {
+        schedule.Run(ctx, schedule.Options{Spec: schedule.Every(20 * time.Millisecond), RunOnStart: true}, func(ctx context.Context) error {
+            n := atomic.AddInt32(&active, 1)
+            defer atomic.AddInt32(&active, -1)
+            if n > atomic.LoadInt32(&maxActive) { atomic.StoreInt32(&maxActive, n) }
+            atomic.AddInt32(&runs, 1)
+            // 阻塞到取消：期间的所有触发都应被跳过
+            <-ctx.Done()
+            cancelled.Store(true)
+            return nil
+        })
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-time.After(2 * time.Second):
+        t.Fatal("Run did not return after cancel")
+    }
+    if maxActive != 1 { t.Fatalf("overlapping runs: max active=%d", maxActive) }
+    if r := atomic.LoadInt32(&runs); r != 1 { t.Fatalf("runs=%d, want 1 (later ticks skipped)", r) }
+    if !cancelled.Load() { t.Fatalf("running job should observe ctx cancellation") }
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "testing"
+    "time"
+
+    "gopkg.in/yaml.v3"
+
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/friends"
+    "go-circle-of-friends/internal/sources"
+)
+
+func TestFriends_ParseFriendBlock(t *testing.T) {
+    cases := []struct {
+        body string
+        want config.StaticFriend
+    }{
+        {"申请友链\n\n```yaml\nname: A\nlink: https://a.example\navatar: https://a.example/a.png\n```\n谢谢", config.StaticFriend{Name: "A", Link: "https://a.example", Avatar: "https://a.example/a.png"}},
+        {"```json\r\n{\"title\": \"B\", \"url\": \"https://b.example\", \"rss\": \"https://b.example/rss\"}\r\n```", config.StaticFriend{Name: "B", Link: "https://b.example", Feed: "https://b.example/rss"}},
+        {"```\nnot: [valid\n```\n~~~yml\nname: C\nlink: https://c.example\n~~~", config.StaticFriend{Name: "C", Link: "https://c.example"}},
+        {"name: D\nlink: https://d.example", config.StaticFriend{Name: "D", Link: "https://d.example"}},
+    }
+    for i, c := range cases {
+        got, err := friends.ParseFriendBlock(c.body)
+        if err != nil || len(got) != 1 || got[0] != c.want { t.Fatalf("case %d: %v %+v", i, err, got) }
+    }
+    if _, err := friends.ParseFriendBlock("只是一段话"); err != friends.ErrNoFriendBlock { t.Fatalf("err=%v", err) }
+}
+
+// issuesAPI 模拟 GitHub/Gitea 的 issues 接口（prefix 为 API 路径前缀，Gitea 为 /api/v1；
+// maxPer 大于 0 时模拟服务端把每页条数压到该值）。
+func issuesAPI(t *testing.T, prefix string, maxPer int, issues []map[string]any) *httptest.Server {
+    return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != prefix+"/repos/me/blog/issues" { http.NotFound(w, r); return }
+        q := r.URL.Query()
+        if q.Get("labels") != "friend" || q.Get("state") != "all" { t.Errorf("query=%s", r.URL.RawQuery) }
+        if r.Header.Get("Authorization") != "token s3cret" { w.WriteHeader(http.StatusUnauthorized); return }
+        per, _ := strconv.Atoi(q.Get("per_page"))
+        if maxPer > 0 && per > maxPer { per = maxPer }
+        page, _ := strconv.Atoi(q.Get("page"))
+        start := (page - 1) * per
+        end := min(start+per, len(issues))
+        if start > len(issues) { start = len(issues) }
+        // GitHub 风格（无前缀）在还有下一页时返回 Link 头
+        if prefix == "" && end < len(issues) {
+            w.Header().Set("Link", `<`+r.URL.Path+`?page=`+strconv.Itoa(page+1)+`>; rel="next", <`+r.URL.Path+`?page=1>; rel="first"`)
+        } else if prefix == "" {
+            w.Header().Set("Link", `<`+r.URL.Path+`?page=1>; rel="first"`)
+        }
+        w.Header().Set("Content-Type", "application/json")
+        _ = json.NewEncoder(w).Encode(issues[start:end])
+    }))
+}
+
+func TestSources_Issues(t *testing.T) {
+    issues := []map[string]any{
+        {"number": 1, "title": "Alice 的博客", "body": "```yaml\nlink: https://alice.example\navatar: https://alice.example/a.png\n```"},
+        {"number": 2, "title": "PR", "body": "```yaml\nname: X\nlink: https://x.example\n```", "pull_request": map[string]any{"url": "x"}},
+        {"number": 3, "title": "no block", "body": "请加我"},
+        {"number": 4, "title": "Bob", "body": "```json\n{\"name\": \"Bob\", \"link\": \"https://bob.example\", \"feed\": \"https://bob.example/atom.xml\"}\n```", "pull_request": nil},
+        {"number": 5, "title": "Carol", "body": "```yaml\nname: Carol\nlink: https://carol.example\n```"},
+    }
+    t.Setenv("COF_TEST_TOKEN", "s3cret")
+    for _, prefix := range []string{"", "/api/v1"} {
+        srv := issuesAPI(t, prefix, 0, issues)
+        var cfg config.Config
+        err := yaml.Unmarshal([]byte(`
+LINK:
+  - type: issues
+    url: `+srv.URL+prefix+`
+    options:
+      repo: me/blog
+      label: friend
+      state: all
+      token_env: COF_TEST_TOKEN
+      per_page: 2
+`), &cfg)
+        if err != nil { t.Fatalf("yaml: %v", err) }
+        cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second})
+        res := sources.Collect(context.Background(), cfg.LinkSources, sources.Deps{Fetch: cl})
+        srv.Close()
+        if len(res) != 1 || res[0].Err != nil { t.Fatalf("prefix %q: %+v", prefix, res) }
+        got := res[0].Friends
+        // 跨 3 页读取；跳过 PR 与无法解析的 issue；缺少名称时使用标题
+        if len(got) != 3 || got[0].Name != "Alice 的博客" || got[1].Feed != "https://bob.example/atom.xml" || got[2].Name != "Carol" { t.Fatalf("prefix %q friends=%+v", prefix, got) }
+    }
+
+    // 令牌错误时整个来源报错
+    srv := issuesAPI(t, "", 0, issues)
+    defer srv.Close()
+    src := config.LinkSource{Type: "issues", URL: srv.URL}
+    _ = src.Options.Encode(map[string]any{"repo": "me/blog", "label": "friend", "state": "all", "token": "wrong"})
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, Retry: 0})
+    if _, err := sourceFriends(context.Background(), cl, src); err == nil { t.Fatalf("expected unauthorized error") }
+
+    for _, opts := range []map[string]any{{"repo": "blog"}, {"repo": "me/blog", "state": "merged"}} {
+        bad := config.LinkSource{Type: "issues"}
+        _ = bad.Options.Encode(opts)
+        if err := sources.Validate([]config.LinkSource{bad}); err == nil { t.Fatalf("expected error for %v", opts) }
+    }
+}
+
+func TestSources_IssuesPageSizeCappedByServer(t *testing.T) {
+    var issues []map[string]any
+    for i := 1; i <= 7; i++ {
+        issues = append(issues, map[string]any{"number": i, "title": "F" + strconv.Itoa(i), "body": "link: https://f" + strconv.Itoa(i) + ".example"})
+    }
+    // 请求 per_page=5，服务端每页最多返回 2 条（如 Gitea 的 MAX_RESPONSE_ITEMS）
+    srv := issuesAPI(t, "/api/v1", 2, issues)
+    defer srv.Close()
+    src := config.LinkSource{Type: "issues", URL: srv.URL + "/api/v1"}
+    _ = src.Options.Encode(map[string]any{"repo": "me/blog", "label": "friend", "state": "all", "token": "s3cret", "per_page": 5})
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second})
+    got, err := sourceFriends(context.Background(), cl, src)
+    if err != nil { t.Fatalf("friends: %v", err) }
+    if len(got) != 7 || got[6].Name != "F7" { t.Fatalf("friends=%+v", got) }
+}
+
+func TestSources_IssuesAllowPrivateAPI(t *testing.T) {
+    issues := []map[string]any{{"number": 1, "title": "A", "body": "link: https://a.example"}}
+    srv := issuesAPI(t, "/api/v1", 0, issues)
+    defer srv.Close()
+    // 默认拒绝内网地址，本地 API 需在来源上单独放行
+    cl, _ := fetch.New(fetch.Options{Timeout: 3 * time.Second, BlockPrivate: true})
+    opts := map[string]any{"repo": "me/blog", "label": "friend", "state": "all", "token": "s3cret"}
+    src := config.LinkSource{Type: "issues", URL: srv.URL + "/api/v1"}
+    _ = src.Options.Encode(opts)
+    if _, err := sourceFriends(context.Background(), cl, src); !errors.Is(err, fetch.ErrPrivateAddress) { t.Fatalf("err=%v want ErrPrivateAddress", err) }
+    opts["allow_private"] = true
+    _ = src.Options.Encode(opts)
+    got, err := sourceFriends(context.Background(), cl, src)
+    if err != nil || len(got) != 1 || got[0].Name != "A" { t.Fatalf("friends=%+v err=%v", got, err) }
+    // 放行只针对该 API 主机，其他请求仍被拒绝
+    if _, err := cl.Get(context.Background(), srv.URL+"/api/v1/repos/me/blog/issues"); !errors.Is(err, fetch.ErrPrivateAddress) { t.Fatalf("plain get err=%v", err) }
+}
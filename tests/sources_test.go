@@ -0,0 +1,96 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "gopkg.in/yaml.v3"
+
+    "go-circle-of-friends/internal/aggregate"
+    "go-circle-of-friends/internal/config"
+    "go-circle-of-friends/internal/fetch"
+    "go-circle-of-friends/internal/rules"
+    "go-circle-of-friends/internal/sources"
+)
+
+// sourceFriends 通过注册表创建来源并返回朋友列表。
+func sourceFriends(ctx context.Context, cl fetch.Fetcher, src config.LinkSource) ([]config.StaticFriend, error) {
+    s, err := sources.New(src, sources.Deps{Fetch: cl})
+    if err != nil { return nil, err }
+    return s.Friends(ctx)
+}
+
+// staticSource 为测试注册的来源：从 options 读取朋友列表。
+type staticSource struct {
+    list []config.StaticFriend
+    err  string
+}
+
+func (s *staticSource) Friends(context.Context) ([]config.StaticFriend, error) {
+    if s.err != "" { return nil, errors.New(s.err) }
+    return s.list, nil
+}
+
+func init() {
+    sources.Register("test-static", func(src config.LinkSource, _ sources.Deps) (sources.FriendSource, error) {
+        var opts struct {
+            Friends []config.StaticFriend `yaml:"friends"`
+            Fail    string                `yaml:"fail"`
+        }
+        if err := src.DecodeOptions(&opts); err != nil { return nil, err }
+        return &staticSource{list: opts.Friends, err: opts.Fail}, nil
+    })
+}
+
+func TestSources_RegistryAndOptions(t *testing.T) {
+    var cfg config.Config
+    err := yaml.Unmarshal([]byte(`
+LINK:
+  - type: test-static
+    options:
+      friends:
+        - {name: A, link: "https://a.example", feed: "https://a.example/feed"}
+  - type: test-static
+    options: {fail: "upstream down"}
+`), &cfg)
+    if err != nil { t.Fatalf("yaml: %v", err) }
+    if err := sources.Validate(cfg.LinkSources); err != nil { t.Fatalf("validate: %v", err) }
+    found := false
+    for _, typ := range sources.Types() {
+        if typ == "test-static" { found = true }
+    }
+    if !found { t.Fatalf("types=%v", sources.Types()) }
+
+    // 单个来源失败不影响其他来源，错误按来源记录
+    res := sources.Collect(context.Background(), cfg.LinkSources, sources.Deps{})
+    if len(res) != 2 || res[0].Err != nil || len(res[0].Friends) != 1 || res[0].Friends[0].Feed != "https://a.example/feed" { t.Fatalf("res[0]=%+v", res[0]) }
+    if res[1].Err == nil || res[1].Err.Error() != "upstream down" { t.Fatalf("res[1]=%+v", res[1]) }
+
+    // 配置块类型不符时在创建时报错
+    var bad config.Config
+    _ = yaml.Unmarshal([]byte("LINK:\n  - type: test-static\n    options: {friends: 3}\n"), &bad)
+    if err := sources.Validate(bad.LinkSources); err == nil { t.Fatalf("expected options error") }
+    if _, err := sources.New(config.LinkSource{Type: "nope"}, sources.Deps{}); err == nil { t.Fatalf("expected unknown type error") }
+}
+
+func TestSources_RunnerReportsPerSource(t *testing.T) {
+    fk := fetch.NewFake().SetBody("https://a.example/feed", "application/rss+xml", `<rss version="2.0"><channel><title>a</title></channel></rss>`)
+    var cfg config.Config
+    _ = yaml.Unmarshal([]byte(`
+SIMPLE_MODE: true
+LINK:
+  - type: test-static
+    options:
+      friends: [{name: A, link: "https://a.example", feed: "https://a.example/feed"}]
+  - type: test-static
+    options: {fail: "boom"}
+`), &cfg)
+    if err := cfg.Validate(); err != nil { t.Fatalf("validate: %v", err) }
+    run := aggregate.New(&cfg, nil, fk, &rules.Rules{})
+    if err := run.Run(context.Background()); err != nil { t.Fatalf("run: %v", err) }
+    rs := run.SourceResults()
+    if len(rs) != 2 || rs[0].Err != nil || rs[1].Err == nil { t.Fatalf("results=%+v", rs) }
+    fr, _ := run.BufferData()
+    if len(fr) != 1 || fr[0].Name != "A" || fr[0].Error != "" { t.Fatalf("friends=%+v", fr) }
+}